@@ -0,0 +1,167 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// the shortest content worth keeping when a message is truncated or summarized
+	minContentTokens = 16
+	summarizePrompt  = `Summarize the text below in at most %d words.
+Keep all names, numbers and facts, skip any comments.`
+)
+
+var (
+	ErrContextExceeded = errors.New("prompt does not fit into the model context window")
+)
+
+// BudgetStrategy shrinks the conversation until its prompt fits into maxTokens
+type BudgetStrategy interface {
+	Fit(ctx context.Context, c *Chat, conv *Conversation, maxTokens int) error
+}
+
+// WithBudget keeps every request under the model context window, leaving room for the reply
+func WithBudget(strategy BudgetStrategy) Option {
+	return func(c *Chat) {
+		c.budget = strategy
+	}
+}
+
+// TruncateOldest drops the oldest messages, system messages and the last message are kept
+func TruncateOldest() BudgetStrategy {
+	return truncateOldest{}
+}
+
+// TruncateLongest cuts the content of the longest messages
+func TruncateLongest() BudgetStrategy {
+	return truncateLongest{}
+}
+
+// Summarize replaces the content of the longest messages with their summaries made by the chat model
+func Summarize() BudgetStrategy {
+	return summarize{}
+}
+
+type truncateOldest struct{}
+
+func (truncateOldest) Fit(_ context.Context, _ *Chat, conv *Conversation, maxTokens int) error {
+	return conv.Trim(maxTokens)
+}
+
+type truncateLongest struct{}
+
+func (truncateLongest) Fit(_ context.Context, c *Chat, conv *Conversation, maxTokens int) error {
+	return shrinkLongest(conv, maxTokens, func(_ int, content string, tokens int) (string, error) {
+		return TruncateText(c.model, content, tokens)
+	})
+}
+
+type summarize struct{}
+
+// Fit summarizes every message at most once, summaries which are still too long are truncated
+func (summarize) Fit(ctx context.Context, c *Chat, conv *Conversation, maxTokens int) error {
+	summarized := make(map[int]bool)
+	return shrinkLongest(conv, maxTokens, func(i int, content string, tokens int) (string, error) {
+		if summarized[i] {
+			return TruncateText(c.model, content, tokens)
+		}
+		summarized[i] = true
+		return c.summarize(ctx, content, tokens)
+	})
+}
+
+// shrinkLongest shortens the longest message with plain content until the conversation fits
+func shrinkLongest(conv *Conversation, maxTokens int, shrink func(i int, content string, tokens int) (string, error)) error {
+	for tokens := conv.Tokens(); tokens > maxTokens; tokens = conv.Tokens() {
+		longest := -1
+		longestTokens := 0
+		for i, msg := range conv.messages {
+			if len(msg.MultiContent) != 0 || msg.Content == "" {
+				continue
+			}
+			if msgTokens := messageTokens(msg); msgTokens > longestTokens {
+				longest, longestTokens = i, msgTokens
+			}
+		}
+		if longest < 0 {
+			return fmt.Errorf("%w: %d tokens of %d", ErrContextExceeded, tokens, maxTokens)
+		}
+		target := contentTokens(conv.messages[longest]) - (tokens - maxTokens)
+		if target < minContentTokens {
+			return fmt.Errorf("%w: %d tokens of %d", ErrContextExceeded, tokens, maxTokens)
+		}
+		shrunk, err := shrink(longest, conv.messages[longest].Content, target)
+		if err != nil {
+			return fmt.Errorf("failed to shrink message: %v", err)
+		}
+		if shrunk == conv.messages[longest].Content {
+			return fmt.Errorf("%w: %d tokens of %d", ErrContextExceeded, tokens, maxTokens)
+		}
+		conv.messages[longest].Content = shrunk
+	}
+	return nil
+}
+
+func contentTokens(msg openai.ChatCompletionMessage) int {
+	tokens, err := CountTextTokens(tokenizerModel, msg.Content)
+	if err != nil {
+		return len([]rune(msg.Content)) / charsPerToken
+	}
+	return tokens
+}
+
+// summarize splits the content into chunks fitting the context window and summarizes each of them
+func (c *Chat) summarize(ctx context.Context, content string, maxTokens int) (string, error) {
+	chunkTokens := ContextWindow(c.model) / 2
+	enc, err := encoding(c.model)
+	if err != nil {
+		return "", err
+	}
+	tokens := enc.EncodeOrdinary(content)
+	chunks := (len(tokens) + chunkTokens - 1) / chunkTokens
+	// a word is about one and a third of a token
+	words := maxTokens * 3 / 4 / chunks
+	var summaries []string
+	for start := 0; start < len(tokens); start += chunkTokens {
+		end := min(start+chunkTokens, len(tokens))
+		conv := NewConversation(fmt.Sprintf(summarizePrompt, words)).AddUser(enc.Decode(tokens[start:end]))
+		req := newRequest(c.model, conv)
+		req.MaxTokens = maxTokens / chunks
+		msg, err := c.send(ctx, req)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize: %v", err)
+		}
+		summaries = append(summaries, msg.Content)
+	}
+	summary := strings.Join(summaries, "\n")
+	log.Printf("summarized %d tokens into %d words", len(tokens), len(strings.Fields(summary)))
+	return summary, nil
+}
+
+// fitBudget makes sure the request prompt leaves room for the reply within the model context window
+func (c *Chat) fitBudget(ctx context.Context, req *openai.ChatCompletionRequest) error {
+	maxTokens := ContextWindow(req.Model) - req.MaxTokens
+	tokens, err := CountTokens(req.Model, req.Messages)
+	if err != nil {
+		return err
+	}
+	if tokens <= maxTokens {
+		return nil
+	}
+	if c.budget == nil {
+		return fmt.Errorf("%w: %d tokens of %d", ErrContextExceeded, tokens, maxTokens)
+	}
+	conv := &Conversation{messages: req.Messages}
+	if err := c.budget.Fit(ctx, c, conv, maxTokens); err != nil {
+		return err
+	}
+	log.Printf("prompt reduced from %d to %d tokens", tokens, conv.Tokens())
+	req.Messages = conv.messages
+	return nil
+}
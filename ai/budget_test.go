@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldCountTokensOfMessages(t *testing.T) {
+	// given
+	msgs := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: "hello world"},
+	}
+
+	// when
+	tokens, err := CountTokens(openai.GPT3Dot5Turbo, msgs)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, tokensPerReply+tokensPerMessage+1+2, tokens)
+}
+
+func TestShouldTruncateTextToTokens(t *testing.T) {
+	// when
+	text, err := TruncateText(openai.GPT4, "one two three four", 2)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "one two", text)
+}
+
+func TestShouldFailWhenPromptExceedsContextWindowWithoutBudget(t *testing.T) {
+	// given
+	provider := NewScripted(Script{Chat: []ScriptedReply{{Content: "some answer"}}})
+	sut := NewChat(provider)
+
+	// when
+	_, err := sut.CompleteChat(context.Background(), strings.Repeat("word ", 5000), "some question")
+
+	// then
+	assert.ErrorIs(t, err, ErrContextExceeded)
+	assert.Empty(t, provider.Requests())
+}
+
+func TestShouldTruncateLongestMessageToFitContextWindow(t *testing.T) {
+	// given
+	provider := NewScripted(Script{Chat: []ScriptedReply{{Content: "some answer"}}})
+	sut := NewChat(provider, WithBudget(TruncateLongest()))
+
+	// when
+	resp, err := sut.CompleteChat(context.Background(), strings.Repeat("word ", 5000), "some question")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some answer", resp)
+	req := provider.Requests()[0]
+	tokens, _ := CountTokens(req.Model, req.Messages)
+	assert.LessOrEqual(t, tokens, ContextWindow(req.Model)-req.MaxTokens)
+	assert.Equal(t, "some question", req.Messages[1].Content)
+}
+
+func TestShouldSummarizeLongestMessageToFitContextWindow(t *testing.T) {
+	// given
+	provider := NewScripted(Script{Chat: []ScriptedReply{
+		{Match: "Summarize the text", Content: "short summary"},
+		{Match: "short summary", Content: "some answer"},
+	}})
+	sut := NewChat(provider, WithBudget(Summarize()))
+
+	// when
+	resp, err := sut.CompleteChat(context.Background(), strings.Repeat("word ", 5000), "some question")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some answer", resp)
+	reqs := provider.Requests()
+	assert.Len(t, reqs, 4)
+	assert.Equal(t, "short summary\nshort summary\nshort summary", reqs[3].Messages[0].Content)
+}
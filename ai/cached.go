@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/koenno/aidevs2/cache"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Cached is a provider reusing responses of identical requests, transcriptions are never cached
+type Cached struct {
+	provider Provider
+	store    *cache.Store
+}
+
+func NewCached(provider Provider, store *cache.Store) *Cached {
+	return &Cached{
+		provider: provider,
+		store:    store,
+	}
+}
+
+func (c *Cached) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return cached(c.store, KindChat, req, func() (openai.ChatCompletionResponse, error) {
+		return c.provider.CreateChatCompletion(ctx, req)
+	})
+}
+
+// CreateChatCompletionStream replays a cached reply as a single delta, fresh replies are cached when the stream finishes
+func (c *Cached) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (ChatStream, error) {
+	key, err := cache.Key(KindChat, req)
+	if err != nil {
+		return c.provider.CreateChatCompletionStream(ctx, req)
+	}
+	var resp openai.ChatCompletionResponse
+	if hit := get(c.store, key, &resp); hit && len(resp.Choices) != 0 {
+		return &replayedStream{
+			choice: resp.Choices[0],
+		}, nil
+	}
+	stream, err := c.provider.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &cachingStream{
+		stream: stream,
+		store:  c.store,
+		key:    key,
+	}, nil
+}
+
+func (c *Cached) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return cached(c.store, KindEmbedding, conv.Convert(), func() (openai.EmbeddingResponse, error) {
+		return c.provider.CreateEmbeddings(ctx, conv)
+	})
+}
+
+func (c *Cached) Moderations(ctx context.Context, req openai.ModerationRequest) (openai.ModerationResponse, error) {
+	return cached(c.store, KindModeration, req, func() (openai.ModerationResponse, error) {
+		return c.provider.Moderations(ctx, req)
+	})
+}
+
+func (c *Cached) CreateTranscription(ctx context.Context, req openai.AudioRequest) (openai.AudioResponse, error) {
+	return c.provider.CreateTranscription(ctx, req)
+}
+
+// cached calls the provider only when there is no cached response, cache failures never fail the call
+func cached[Req, Resp any](store *cache.Store, kind string, req Req, call func() (Resp, error)) (Resp, error) {
+	key, err := cache.Key(kind, req)
+	if err != nil {
+		log.Printf("failed to create cache key: %v", err)
+		return call()
+	}
+	var resp Resp
+	if get(store, key, &resp) {
+		return resp, nil
+	}
+	resp, err = call()
+	if err != nil {
+		return resp, err
+	}
+	put(store, key, resp)
+	return resp, nil
+}
+
+func get(store *cache.Store, key string, v any) bool {
+	hit, err := store.Get(key, v)
+	if err != nil {
+		log.Printf("cache failure: %v", err)
+	}
+	return hit
+}
+
+func put(store *cache.Store, key string, v any) {
+	if err := store.Put(key, v); err != nil {
+		log.Printf("cache failure: %v", err)
+	}
+}
+
+type replayedStream struct {
+	choice openai.ChatCompletionChoice
+	done   bool
+}
+
+func (s *replayedStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return openai.ChatCompletionStreamResponse{}, io.EOF
+	}
+	s.done = true
+	return openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{
+			Delta:        openai.ChatCompletionStreamChoiceDelta{Role: s.choice.Message.Role, Content: s.choice.Message.Content},
+			FinishReason: s.choice.FinishReason,
+		}},
+	}, nil
+}
+
+func (s *replayedStream) Close() {
+}
+
+// cachingStream stores the whole reply once the stream is fully received
+type cachingStream struct {
+	stream       ChatStream
+	store        *cache.Store
+	key          string
+	content      strings.Builder
+	finishReason openai.FinishReason
+}
+
+func (s *cachingStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	resp, err := s.stream.Recv()
+	if errors.Is(err, io.EOF) {
+		put(s.store, s.key, openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{
+					Role:    openai.ChatMessageRoleAssistant,
+					Content: s.content.String(),
+				},
+				FinishReason: s.finishReason,
+			}},
+		})
+	}
+	if err != nil {
+		return resp, err
+	}
+	for _, choice := range resp.Choices {
+		s.content.WriteString(choice.Delta.Content)
+		if choice.FinishReason != "" {
+			s.finishReason = choice.FinishReason
+		}
+	}
+	return resp, nil
+}
+
+func (s *cachingStream) Close() {
+	s.stream.Close()
+}
@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/koenno/aidevs2/cache"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldNotPayForCachedResponses(t *testing.T) {
+	// given
+	store, err := cache.New(t.TempDir())
+	require.NoError(t, err)
+	ledger := NewLedger()
+	scripted := NewScripted(Script{Chat: []ScriptedReply{{Content: "some answer"}}})
+	sut := NewChat(NewCached(NewMetered(scripted, ledger), store))
+	ctx := context.Background()
+
+	// when
+	first, err := sut.ModeratedChat(ctx, "some system", "some question")
+	require.NoError(t, err)
+	second, err := sut.ModeratedChat(ctx, "some system", "some question")
+	require.NoError(t, err)
+	_, err = sut.ModeratedChat(ctx, "some system", "other question")
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, first, second)
+	assert.Len(t, scripted.Requests(), 2)
+	assert.Len(t, ledger.Entries(), 3)
+	assert.Equal(t, cache.Stats{Hits: 3, Misses: 3, Writes: 3}, store.Stats())
+}
+
+func TestShouldReplayCachedStream(t *testing.T) {
+	// given
+	store, err := cache.New(t.TempDir())
+	require.NoError(t, err)
+	scripted := NewScripted(Script{Chat: []ScriptedReply{{Content: "some long answer", FinishReason: openai.FinishReasonLength}}})
+	sut := NewChat(NewCached(scripted, store))
+	ctx := context.Background()
+	ignore := func(string) error { return nil }
+
+	// when
+	first, err := sut.Stream(ctx, NewConversation("").AddUser("some question"), ignore)
+	require.NoError(t, err)
+	second, err := sut.Stream(ctx, NewConversation("").AddUser("some question"), ignore)
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, first, second)
+	assert.True(t, second.Truncated())
+	assert.Len(t, scripted.Requests(), 1)
+}
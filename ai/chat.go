@@ -8,9 +8,10 @@ import (
 )
 
 type Chat struct {
-	client    *openai.Client
+	client    Provider
 	moderator *Moderator
 	model     string
+	budget    BudgetStrategy
 }
 
 type Option func(*Chat)
@@ -21,12 +22,11 @@ func WithModel(m string) Option {
 	}
 }
 
-func NewChat(openaiKey string, opts ...Option) *Chat {
-	client := openai.NewClient(openaiKey)
+func NewChat(provider Provider, opts ...Option) *Chat {
 	chat := &Chat{
-		client: client,
+		client: provider,
 		moderator: &Moderator{
-			client: client,
+			client: provider,
 		},
 		model: openai.GPT3Dot5Turbo,
 	}
@@ -36,103 +36,143 @@ func NewChat(openaiKey string, opts ...Option) *Chat {
 	return chat
 }
 
-func (c *Chat) ModeratedChat(system string, userMsgs ...string) (string, error) {
-	moderationRequired, err := c.moderator.Moderate(context.Background(), system)
+func (c *Chat) ModeratedChat(ctx context.Context, system string, userMsgs ...string) (string, error) {
+	moderationRequired, err := c.moderator.Moderate(ctx, system)
 	if err != nil {
 		return "", fmt.Errorf("failed to moderate entry: %v", err)
 	}
 	if moderationRequired {
 		return "", fmt.Errorf("entry breaks openai usage policies: %s", system)
 	}
-	resp, err := c.CompleteChat(system, userMsgs...)
+	resp, err := c.CompleteChat(ctx, system, userMsgs...)
 	if err != nil {
 		return "", fmt.Errorf("failed to complete moderated chat: %v", err)
 	}
 	return resp, nil
 }
 
-func (c *Chat) ModeratedFunctionCalling(system, user, assistant string, funcDefs []openai.FunctionDefinition) (*openai.FunctionCall, error) {
+func (c *Chat) ModeratedFunctionCalling(ctx context.Context, system, user, assistant string, funcDefs []openai.FunctionDefinition) (*openai.FunctionCall, error) {
 	msg := system + user + assistant
-	moderationRequired, err := c.moderator.Moderate(context.Background(), msg)
+	moderationRequired, err := c.moderator.Moderate(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to moderate entry: %v", err)
 	}
 	if moderationRequired {
 		return nil, fmt.Errorf("entry breaks openai usage policies: %s", system)
 	}
-	resp, err := c.FunctionCalling(system, user, assistant, funcDefs)
+	resp, err := c.FunctionCalling(ctx, system, user, assistant, funcDefs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute moderated function calling: %v", err)
 	}
 	return resp, nil
 }
 
-func (c *Chat) CompleteChat(system string, userMsgs ...string) (string, error) {
-	msgs := []openai.ChatCompletionMessage{
-		{
-			Role:    openai.ChatMessageRoleSystem,
-			Content: system,
-		},
-	}
+func (c *Chat) CompleteChat(ctx context.Context, system string, userMsgs ...string) (string, error) {
+	conv := NewConversation("")
+	conv.AddSystem(system)
 	for _, userMsg := range userMsgs {
-		msg := openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleUser,
-			Content: userMsg,
-		}
-		msgs = append(msgs, msg)
-	}
-	req := openai.ChatCompletionRequest{
-		Model:       c.model,
-		Messages:    msgs,
-		MaxTokens:   250,
-		Temperature: 0,
-		TopP:        1,
-		N:           1,
-		Stream:      false,
-	}
-	resp, err := c.client.CreateChatCompletion(context.Background(), req)
+		conv.AddUser(userMsg)
+	}
+	return c.Complete(ctx, conv)
+}
+
+func (c *Chat) FunctionCalling(ctx context.Context, system, user, assistant string, funcDefs []openai.FunctionDefinition) (*openai.FunctionCall, error) {
+	conv := NewConversation("")
+	conv.AddSystem(system).AddUser(user).AddAssistant(assistant)
+	return c.CompleteFunctionCall(ctx, conv, funcDefs)
+}
+
+// ModeratedComplete moderates everything but assistant messages before completing the conversation
+func (c *Chat) ModeratedComplete(ctx context.Context, conv *Conversation) (string, error) {
+	if err := c.moderate(ctx, conv); err != nil {
+		return "", err
+	}
+	resp, err := c.Complete(ctx, conv)
 	if err != nil {
-		return "", fmt.Errorf("response failure for chat completion: %v", err)
+		return "", fmt.Errorf("failed to complete moderated conversation: %v", err)
 	}
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("empty response received")
+	return resp, nil
+}
+
+// Complete sends the whole conversation and appends the assistant reply to it
+func (c *Chat) Complete(ctx context.Context, conv *Conversation) (string, error) {
+	msg, err := c.complete(ctx, newRequest(c.model, conv))
+	if err != nil {
+		return "", err
 	}
-	return resp.Choices[0].Message.Content, nil
+	conv.Add(msg)
+	return msg.Content, nil
 }
 
-func (c *Chat) FunctionCalling(system, user, assistant string, funcDefs []openai.FunctionDefinition) (*openai.FunctionCall, error) {
-	req := openai.ChatCompletionRequest{
-		Model: c.model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: system,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: user,
-			},
-			{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: assistant,
-			},
-		},
-		Functions:   funcDefs,
-		MaxTokens:   250,
-		Temperature: 0,
-		TopP:        1,
-		N:           1,
-		Stream:      false,
-	}
-	resp, err := c.client.CreateChatCompletion(context.Background(), req)
+func (c *Chat) ModeratedCompleteFunctionCall(ctx context.Context, conv *Conversation, funcDefs []openai.FunctionDefinition) (*openai.FunctionCall, error) {
+	if err := c.moderate(ctx, conv); err != nil {
+		return nil, err
+	}
+	resp, err := c.CompleteFunctionCall(ctx, conv, funcDefs)
 	if err != nil {
-		return nil, fmt.Errorf("response failure for chat completion: %v", err)
+		return nil, fmt.Errorf("failed to execute moderated function calling: %v", err)
+	}
+	return resp, nil
+}
+
+// CompleteFunctionCall asks which function should be called next and appends the assistant call to the conversation
+func (c *Chat) CompleteFunctionCall(ctx context.Context, conv *Conversation, funcDefs []openai.FunctionDefinition) (*openai.FunctionCall, error) {
+	req := newRequest(c.model, conv)
+	req.Functions = funcDefs
+	msg, err := c.complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if msg.FunctionCall == nil {
+		return nil, fmt.Errorf("failed to call function: %#v", msg)
+	}
+	conv.Add(msg)
+	return msg.FunctionCall, nil
+}
+
+// Replay sends user messages of the conversation one by one and returns a new conversation with fresh assistant replies
+func (c *Chat) Replay(ctx context.Context, conv *Conversation) (*Conversation, error) {
+	replayed := NewConversation("")
+	for _, msg := range conv.Messages() {
+		switch msg.Role {
+		case openai.ChatMessageRoleSystem:
+			replayed.Add(msg)
+		case openai.ChatMessageRoleUser:
+			replayed.Add(msg)
+			if _, err := c.Complete(ctx, replayed); err != nil {
+				return nil, fmt.Errorf("failed to replay conversation: %v", err)
+			}
+		}
+	}
+	return replayed, nil
+}
+
+func (c *Chat) complete(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionMessage, error) {
+	if err := c.fitBudget(ctx, &req); err != nil {
+		return openai.ChatCompletionMessage{}, fmt.Errorf("failed to fit prompt into budget: %w", err)
+	}
+	return c.send(ctx, req)
+}
+
+func (c *Chat) send(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionMessage, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return openai.ChatCompletionMessage{}, fmt.Errorf("response failure for chat completion: %v", err)
 	}
 	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("empty response received")
+		return openai.ChatCompletionMessage{}, fmt.Errorf("empty response received")
 	}
-	if resp.Choices[0].Message.FunctionCall == nil {
-		return nil, fmt.Errorf("failed to call function: %#v", resp)
+	return resp.Choices[0].Message, nil
+}
+
+func (c *Chat) moderate(ctx context.Context, conv *Conversation) error {
+	entry := conv.Text(openai.ChatMessageRoleSystem, openai.ChatMessageRoleUser)
+	moderationRequired, err := c.moderator.Moderate(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to moderate entry: %v", err)
+	}
+	if moderationRequired {
+		return fmt.Errorf("entry breaks openai usage policies: %s", entry)
 	}
-	return resp.Choices[0].Message.FunctionCall, nil
+	return nil
 }
@@ -0,0 +1,235 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// rough estimation used when the tokenizer is not available, one token is about four characters
+	charsPerToken   = 4
+	defaultMaxReply = 250
+	// all chat models share the same tokenizer
+	tokenizerModel = openai.GPT3Dot5Turbo
+)
+
+// Conversation accumulates messages of a multi-turn chat
+type Conversation struct {
+	messages []openai.ChatCompletionMessage
+}
+
+// NewConversation starts a conversation with an optional system message
+func NewConversation(system string) *Conversation {
+	c := &Conversation{}
+	if system != "" {
+		c.AddSystem(system)
+	}
+	return c
+}
+
+func (c *Conversation) Add(msg openai.ChatCompletionMessage) *Conversation {
+	c.messages = append(c.messages, msg)
+	return c
+}
+
+func (c *Conversation) AddSystem(content string) *Conversation {
+	return c.Add(openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: content,
+	})
+}
+
+func (c *Conversation) AddUser(content string) *Conversation {
+	return c.Add(openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: content,
+	})
+}
+
+// AddUserImage adds a user message asking about the image under the given URI
+func (c *Conversation) AddUserImage(content, imageURI string) *Conversation {
+	return c.Add(openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleUser,
+		MultiContent: []openai.ChatMessagePart{
+			{
+				Type: openai.ChatMessagePartTypeText,
+				Text: content,
+			},
+			{
+				Type: openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{
+					URL:    imageURI,
+					Detail: openai.ImageURLDetailAuto,
+				},
+			},
+		},
+	})
+}
+
+func (c *Conversation) AddAssistant(content string) *Conversation {
+	return c.Add(openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: content,
+	})
+}
+
+// AddFunctionResult adds the result of a function the assistant asked to call
+func (c *Conversation) AddFunctionResult(name, content string) *Conversation {
+	return c.Add(openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleFunction,
+		Name:    name,
+		Content: content,
+	})
+}
+
+// AddToolResult adds the result of a tool call the assistant asked for
+func (c *Conversation) AddToolResult(toolCallID, name, content string) *Conversation {
+	return c.Add(openai.ChatCompletionMessage{
+		Role:       openai.ChatMessageRoleTool,
+		ToolCallID: toolCallID,
+		Name:       name,
+		Content:    content,
+	})
+}
+
+// Messages returns a copy of all messages in the order they were added
+func (c *Conversation) Messages() []openai.ChatCompletionMessage {
+	return append([]openai.ChatCompletionMessage(nil), c.messages...)
+}
+
+func (c *Conversation) Len() int {
+	return len(c.messages)
+}
+
+// Last returns the most recent message
+func (c *Conversation) Last() (openai.ChatCompletionMessage, bool) {
+	if len(c.messages) == 0 {
+		return openai.ChatCompletionMessage{}, false
+	}
+	return c.messages[len(c.messages)-1], true
+}
+
+func (c *Conversation) Clone() *Conversation {
+	return &Conversation{
+		messages: c.Messages(),
+	}
+}
+
+// Text joins contents of all messages written by the given roles, all roles are used when none given
+func (c *Conversation) Text(roles ...string) string {
+	var parts []string
+	for _, msg := range c.messages {
+		if len(roles) != 0 && !slices.Contains(roles, msg.Role) {
+			continue
+		}
+		parts = append(parts, messageText(msg))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Tokens counts how many prompt tokens the conversation takes
+func (c *Conversation) Tokens() int {
+	tokens := tokensPerReply
+	for _, msg := range c.messages {
+		tokens += messageTokens(msg)
+	}
+	return tokens
+}
+
+// Trim drops the oldest messages until the conversation fits into the given token budget.
+// System messages and the last message are always kept. Tool and function results are dropped
+// together with the assistant call they answer as OpenAI rejects results without their call.
+func (c *Conversation) Trim(maxTokens int) error {
+	tokens := c.Tokens()
+	for i := 0; tokens > maxTokens && i < len(c.messages)-1; {
+		if c.messages[i].Role == openai.ChatMessageRoleSystem {
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(c.messages) && isResult(c.messages[end]) {
+			end++
+		}
+		if end == len(c.messages) {
+			// the last message answers the call so neither can be dropped
+			break
+		}
+		for _, msg := range c.messages[i:end] {
+			tokens -= messageTokens(msg)
+		}
+		c.messages = append(c.messages[:i], c.messages[end:]...)
+	}
+	if tokens > maxTokens {
+		return fmt.Errorf("%w: conversation takes %d tokens of %d", ErrContextExceeded, tokens, maxTokens)
+	}
+	return nil
+}
+
+func isResult(msg openai.ChatCompletionMessage) bool {
+	return msg.Role == openai.ChatMessageRoleTool || msg.Role == openai.ChatMessageRoleFunction
+}
+
+func (c *Conversation) MarshalJSON() ([]byte, error) {
+	msgs := c.messages
+	if msgs == nil {
+		msgs = []openai.ChatCompletionMessage{}
+	}
+	return json.Marshal(msgs)
+}
+
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var msgs []openai.ChatCompletionMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return fmt.Errorf("failed to decode conversation: %v", err)
+	}
+	c.messages = msgs
+	return nil
+}
+
+func newRequest(model string, conv *Conversation) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:       model,
+		Messages:    conv.Messages(),
+		MaxTokens:   defaultMaxReply,
+		Temperature: 0,
+		TopP:        1,
+		N:           1,
+		Stream:      false,
+	}
+}
+
+func messageText(msg openai.ChatCompletionMessage) string {
+	if len(msg.MultiContent) == 0 {
+		return msg.Content
+	}
+	var parts []string
+	for _, part := range msg.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func messageTokens(msg openai.ChatCompletionMessage) int {
+	enc, err := encoding(tokenizerModel)
+	if err != nil {
+		return estimateTokens(msg)
+	}
+	return countMessageTokens(enc, msg)
+}
+
+func estimateTokens(msg openai.ChatCompletionMessage) int {
+	text := messageText(msg)
+	if msg.FunctionCall != nil {
+		text += msg.FunctionCall.Name + msg.FunctionCall.Arguments
+	}
+	for _, call := range msg.ToolCalls {
+		text += call.Function.Name + call.Function.Arguments
+	}
+	return tokensPerMessage + (len([]rune(text))+charsPerToken-1)/charsPerToken
+}
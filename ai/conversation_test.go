@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldAccumulateMessagesWithRoles(t *testing.T) {
+	// given
+	sut := NewConversation("some system")
+
+	// when
+	sut.AddUser("some question").
+		AddAssistant("some answer").
+		AddFunctionResult("someFunc", "some result").
+		AddToolResult("call_1", "someTool", "some tool result")
+
+	// then
+	msgs := sut.Messages()
+	assert.Len(t, msgs, 5)
+	roles := make([]string, len(msgs))
+	for i, msg := range msgs {
+		roles[i] = msg.Role
+	}
+	assert.Equal(t, []string{
+		openai.ChatMessageRoleSystem,
+		openai.ChatMessageRoleUser,
+		openai.ChatMessageRoleAssistant,
+		openai.ChatMessageRoleFunction,
+		openai.ChatMessageRoleTool,
+	}, roles)
+	assert.Equal(t, "call_1", msgs[4].ToolCallID)
+}
+
+func TestShouldSerializeAndRestoreConversation(t *testing.T) {
+	// given
+	sut := NewConversation("some system")
+	sut.AddUser("some question").AddAssistant("some answer")
+
+	// when
+	bb, err := json.Marshal(sut)
+	assert.NoError(t, err)
+	var restored Conversation
+	err = json.Unmarshal(bb, &restored)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, sut.Messages(), restored.Messages())
+}
+
+func TestShouldTrimOldestMessagesButKeepSystemAndLast(t *testing.T) {
+	// given
+	long := strings.Repeat("word ", 100)
+	sut := NewConversation("some system")
+	sut.AddUser(long).AddAssistant(long).AddUser("last question")
+
+	// when
+	err := sut.Trim(50)
+
+	// then
+	assert.NoError(t, err)
+	msgs := sut.Messages()
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, "some system", msgs[0].Content)
+	assert.Equal(t, "last question", msgs[1].Content)
+}
+
+func TestShouldReturnErrorWhenConversationCannotBeTrimmed(t *testing.T) {
+	// given
+	sut := NewConversation(strings.Repeat("word ", 100))
+	sut.AddUser("question")
+
+	// when
+	err := sut.Trim(10)
+
+	// then
+	assert.ErrorIs(t, err, ErrContextExceeded)
+	assert.Equal(t, 2, sut.Len())
+}
+
+func TestShouldTrimToolCallTogetherWithItsResults(t *testing.T) {
+	// given
+	long := strings.Repeat("word ", 100)
+	sut := NewConversation("some system").AddUser("some question")
+	sut.Add(openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{
+			{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "search", Arguments: "{}"}},
+			{ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "search", Arguments: "{}"}},
+		},
+	})
+	sut.AddToolResult("call_1", "search", long).AddToolResult("call_2", "search", long)
+	sut.AddAssistant("some answer").AddUser("last question")
+
+	// when
+	err := sut.Trim(60)
+
+	// then
+	assert.NoError(t, err)
+	var roles []string
+	for _, msg := range sut.Messages() {
+		roles = append(roles, msg.Role)
+	}
+	assert.Equal(t, []string{openai.ChatMessageRoleSystem, openai.ChatMessageRoleAssistant, openai.ChatMessageRoleUser}, roles)
+}
+
+func TestShouldKeepToolCallAnsweredByLastMessage(t *testing.T) {
+	// given
+	long := strings.Repeat("word ", 100)
+	sut := NewConversation("some system").AddUser(long)
+	sut.Add(openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "search", Arguments: "{}"}}},
+	})
+	sut.AddToolResult("call_1", "search", long)
+
+	// when
+	err := sut.Trim(50)
+
+	// then
+	assert.ErrorIs(t, err, ErrContextExceeded)
+	assert.Equal(t, 3, sut.Len(), "only the user message is dropped")
+}
+
+func TestShouldNotShareMessagesWithClone(t *testing.T) {
+	// given
+	sut := NewConversation("some system")
+
+	// when
+	clone := sut.Clone()
+	clone.AddUser("some question")
+
+	// then
+	assert.Equal(t, 1, sut.Len())
+	assert.Equal(t, 2, clone.Len())
+}
@@ -8,12 +8,12 @@ import (
 )
 
 type Moderator struct {
-	client *openai.Client
+	client Provider
 }
 
-func NewModerator(chat *Chat) *Moderator {
+func NewModerator(provider Provider) *Moderator {
 	return &Moderator{
-		client: chat.client,
+		client: provider,
 	}
 }
 
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/koenno/aidevs2/recording"
+	"github.com/koenno/aidevs2/resilience"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Provider is an LLM backend, its methods mirror the OpenAI API
+type Provider interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+	CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (ChatStream, error)
+	CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+	Moderations(ctx context.Context, req openai.ModerationRequest) (openai.ModerationResponse, error)
+	CreateTranscription(ctx context.Context, req openai.AudioRequest) (openai.AudioResponse, error)
+}
+
+// ChatStream delivers a chat completion piece by piece, Recv returns io.EOF when the stream is finished
+type ChatStream interface {
+	Recv() (openai.ChatCompletionStreamResponse, error)
+	Close()
+}
+
+// OpenAI is the provider backed by the OpenAI API
+type OpenAI struct {
+	client *openai.Client
+}
+
+func NewOpenAI(openaiKey string) *OpenAI {
+	return NewOpenAIWithConfig(openai.DefaultConfig(openaiKey))
+}
+
+// NewOpenAIWithURL creates the provider for the OpenAI API or a compatible one at baseURL, the OpenAI one when empty
+func NewOpenAIWithURL(openaiKey, baseURL string) *OpenAI {
+	cfg := openai.DefaultConfig(openaiKey)
+	if baseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	return NewOpenAIWithConfig(cfg)
+}
+
+// NewOpenAIWithConfig creates the provider, requests are retried by the resilience transport wrapping the configured one and may be recorded or replayed
+func NewOpenAIWithConfig(cfg openai.ClientConfig, opts ...resilience.Option) *OpenAI {
+	httpClient := &http.Client{}
+	if cfg.HTTPClient != nil {
+		*httpClient = *cfg.HTTPClient
+	}
+	httpClient.Transport = recording.Transport(resilience.NewTransport(httpClient.Transport, opts...))
+	cfg.HTTPClient = httpClient
+	return &OpenAI{
+		client: openai.NewClientWithConfig(cfg),
+	}
+}
+
+func (o *OpenAI) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return o.client.CreateChatCompletion(ctx, req)
+}
+
+func (o *OpenAI) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (ChatStream, error) {
+	stream, err := o.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (o *OpenAI) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return o.client.CreateEmbeddings(ctx, conv)
+}
+
+func (o *OpenAI) Moderations(ctx context.Context, req openai.ModerationRequest) (openai.ModerationResponse, error) {
+	return o.client.Moderations(ctx, req)
+}
+
+func (o *OpenAI) CreateTranscription(ctx context.Context, req openai.AudioRequest) (openai.AudioResponse, error) {
+	return o.client.CreateTranscription(ctx, req)
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	descriptionTag = "description"
+	enumTag        = "enum"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// Definition is a JSON schema like jsonschema.Definition which also describes the format of strings
+type Definition struct {
+	Type        jsonschema.DataType   `json:"type,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Format      string                `json:"format,omitempty"`
+	Enum        []string              `json:"enum,omitempty"`
+	Properties  map[string]Definition `json:"properties,omitempty"`
+	Required    []string              `json:"required,omitempty"`
+	Items       *Definition           `json:"items,omitempty"`
+}
+
+// MarshalJSON always writes properties of objects as OpenAI requires them even when there are none
+func (d Definition) MarshalJSON() ([]byte, error) {
+	if d.Type == jsonschema.Object && d.Properties == nil {
+		d.Properties = map[string]Definition{}
+	}
+	type plain Definition
+	if d.Type == jsonschema.Object && len(d.Properties) == 0 {
+		return json.Marshal(struct {
+			plain
+			Properties map[string]Definition `json:"properties"`
+		}{plain(d), d.Properties})
+	}
+	return json.Marshal(plain(d))
+}
+
+// Schema derives a JSON schema from a struct, fields are named after their json tags.
+// A field is required unless it is a pointer or tagged with omitempty.
+// Use `description:"..."` and `enum:"a,b"` tags to describe fields.
+// time.Time is a date-time string, recursive types are not supported.
+func Schema(v any) (Definition, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return Definition{}, fmt.Errorf("cannot derive schema from nil")
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return Definition{}, fmt.Errorf("schema can be derived only from a struct, not a %s", t)
+	}
+	return schemaOf(t, map[reflect.Type]bool{})
+}
+
+// schemaOf derives the schema of the type, visiting holds structs being derived to detect cycles
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) (Definition, error) {
+	if t == timeType {
+		return Definition{Type: jsonschema.String, Format: "date-time"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), visiting)
+	case reflect.String:
+		return Definition{Type: jsonschema.String}, nil
+	case reflect.Bool:
+		return Definition{Type: jsonschema.Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Definition{Type: jsonschema.Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return Definition{Type: jsonschema.Number}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return Definition{}, fmt.Errorf("failed to derive schema of %s items: %v", t, err)
+		}
+		return Definition{Type: jsonschema.Array, Items: &items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return Definition{}, fmt.Errorf("map keys should be strings, not %s", t.Key())
+		}
+		return Definition{Type: jsonschema.Object}, nil
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	default:
+		return Definition{}, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) (Definition, error) {
+	if visiting[t] {
+		return Definition{}, fmt.Errorf("recursive type %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	def := Definition{
+		Type:       jsonschema.Object,
+		Properties: map[string]Definition{},
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitempty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded, err := structSchema(field.Type, visiting)
+			if err != nil {
+				return Definition{}, err
+			}
+			for k, v := range embedded.Properties {
+				def.Properties[k] = v
+			}
+			def.Required = append(def.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		prop, err := schemaOf(field.Type, visiting)
+		if err != nil {
+			return Definition{}, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+		prop.Description = field.Tag.Get(descriptionTag)
+		if enum := field.Tag.Get(enumTag); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+		def.Properties[name] = prop
+		if !omitempty && field.Type.Kind() != reflect.Pointer {
+			def.Required = append(def.Required, name)
+		}
+	}
+	return def, nil
+}
+
+func jsonName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, false
+}
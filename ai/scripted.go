@@ -0,0 +1,266 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// the size of ada v2 embeddings so vectors fit the same collections
+	scriptedEmbeddingSize = 1536
+)
+
+// ScriptedReply is a canned chat reply given when Match is found in the request messages, an empty Match matches any request
+type ScriptedReply struct {
+	Match        string               `json:"match"`
+	Content      string               `json:"content,omitempty"`
+	Call         *openai.FunctionCall `json:"call,omitempty"`
+	FinishReason openai.FinishReason  `json:"finishReason,omitempty"`
+}
+
+// Script describes how the scripted provider responds
+type Script struct {
+	// Chat replies are checked in order, the first matching one is used
+	Chat []ScriptedReply `json:"chat"`
+	// Flagged entries make moderation fail for any input containing them
+	Flagged []string `json:"flagged"`
+	// Transcriptions are keyed by the audio file name
+	Transcriptions map[string]string `json:"transcriptions"`
+}
+
+// LoadScript reads a JSON encoded script
+func LoadScript(path string) (Script, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return Script{}, fmt.Errorf("failed to read script %s: %v", path, err)
+	}
+	var script Script
+	if err := json.Unmarshal(bb, &script); err != nil {
+		return Script{}, fmt.Errorf("failed to decode script %s: %v", path, err)
+	}
+	return script, nil
+}
+
+// Scripted is an offline provider returning canned responses, embeddings are deterministic hashes of words
+type Scripted struct {
+	script   Script
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+}
+
+func NewScripted(script Script) *Scripted {
+	return &Scripted{
+		script: script,
+	}
+}
+
+// Requests returns all chat requests received so far
+func (s *Scripted) Requests() []openai.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), s.requests...)
+}
+
+func (s *Scripted) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	id := len(s.requests)
+	s.mu.Unlock()
+
+	reply, err := s.reply(req)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	msg := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: reply.Content,
+	}
+	finishReason := reply.FinishReason
+	if reply.Call != nil {
+		if len(req.Tools) != 0 {
+			msg.ToolCalls = []openai.ToolCall{{
+				ID:       fmt.Sprintf("call_%d", id),
+				Type:     openai.ToolTypeFunction,
+				Function: *reply.Call,
+			}}
+		} else {
+			msg.FunctionCall = reply.Call
+		}
+	}
+	if finishReason == "" {
+		finishReason = openai.FinishReasonStop
+	}
+	return openai.ChatCompletionResponse{
+		ID:    fmt.Sprintf("scripted-%d", id),
+		Model: req.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      msg,
+			FinishReason: finishReason,
+		}},
+	}, nil
+}
+
+// reply finds the first matching reply, a function call is never repeated right after a tool or function result
+func (s *Scripted) reply(req openai.ChatCompletionRequest) (ScriptedReply, error) {
+	var parts []string
+	for _, msg := range req.Messages {
+		parts = append(parts, messageText(msg))
+	}
+	text := strings.Join(parts, "\n")
+	afterResult := false
+	if len(req.Messages) != 0 {
+		role := req.Messages[len(req.Messages)-1].Role
+		afterResult = role == openai.ChatMessageRoleTool || role == openai.ChatMessageRoleFunction
+	}
+	for _, reply := range s.script.Chat {
+		if reply.Call != nil && (afterResult || len(req.Tools)+len(req.Functions) == 0) {
+			continue
+		}
+		if strings.Contains(text, reply.Match) {
+			return reply, nil
+		}
+	}
+	return ScriptedReply{}, fmt.Errorf("no scripted reply for: %s", text)
+}
+
+func (s *Scripted) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (ChatStream, error) {
+	resp, err := s.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	choice := resp.Choices[0]
+	return &scriptedStream{
+		ctx:          ctx,
+		deltas:       strings.SplitAfter(choice.Message.Content, " "),
+		finishReason: choice.FinishReason,
+	}, nil
+}
+
+func (s *Scripted) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	req := conv.Convert()
+	var inputs []string
+	switch input := req.Input.(type) {
+	case string:
+		inputs = []string{input}
+	case []string:
+		inputs = input
+	default:
+		return openai.EmbeddingResponse{}, fmt.Errorf("unsupported embedding input %T", req.Input)
+	}
+	resp := openai.EmbeddingResponse{
+		Model: req.Model,
+	}
+	for i, input := range inputs {
+		resp.Data = append(resp.Data, openai.Embedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: HashEmbedding(input),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Scripted) Moderations(ctx context.Context, req openai.ModerationRequest) (openai.ModerationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return openai.ModerationResponse{}, err
+	}
+	flagged := false
+	for _, entry := range s.script.Flagged {
+		if strings.Contains(req.Input, entry) {
+			flagged = true
+			break
+		}
+	}
+	return openai.ModerationResponse{
+		Model:   req.Model,
+		Results: []openai.Result{{Flagged: flagged}},
+	}, nil
+}
+
+func (s *Scripted) CreateTranscription(ctx context.Context, req openai.AudioRequest) (openai.AudioResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return openai.AudioResponse{}, err
+	}
+	if req.Reader != nil {
+		io.Copy(io.Discard, req.Reader)
+	}
+	name := filepath.Base(req.FilePath)
+	text, exist := s.script.Transcriptions[name]
+	if !exist {
+		return openai.AudioResponse{}, fmt.Errorf("no scripted transcription for %s", name)
+	}
+	return openai.AudioResponse{
+		Text: text,
+	}, nil
+}
+
+// HashEmbedding returns a normalized bag of words vector, texts sharing words are close to each other
+func HashEmbedding(text string) []float32 {
+	vector := make([]float32, scriptedEmbeddingSize)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vector[h.Sum32()%scriptedEmbeddingSize]++
+	}
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}
+
+type scriptedStream struct {
+	ctx          context.Context
+	deltas       []string
+	finishReason openai.FinishReason
+}
+
+func (s *scriptedStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	if err := s.ctx.Err(); err != nil {
+		return openai.ChatCompletionStreamResponse{}, err
+	}
+	if len(s.deltas) == 0 {
+		return openai.ChatCompletionStreamResponse{}, io.EOF
+	}
+	delta := s.deltas[0]
+	s.deltas = s.deltas[1:]
+	choice := openai.ChatCompletionStreamChoice{
+		Delta: openai.ChatCompletionStreamChoiceDelta{Content: delta},
+	}
+	if len(s.deltas) == 0 {
+		choice.FinishReason = s.finishReason
+	}
+	return openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{choice},
+	}, nil
+}
+
+func (s *scriptedStream) Close() {
+}
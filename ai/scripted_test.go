@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func TestShouldEmbedSimilarTextsCloser(t *testing.T) {
+	// given
+	sut := NewScripted(Script{})
+	req := openai.EmbeddingRequest{
+		Input: []string{"Hawaiian pizza", "pizza Hawaiian!", "cold beer"},
+		Model: openai.AdaEmbeddingV2,
+	}
+
+	// when
+	resp, err := sut.CreateEmbeddings(context.Background(), req)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 3)
+	assert.Len(t, resp.Data[0].Embedding, scriptedEmbeddingSize)
+	assert.InDelta(t, 1, dot(resp.Data[0].Embedding, resp.Data[1].Embedding), 1e-5)
+	assert.InDelta(t, 0, dot(resp.Data[0].Embedding, resp.Data[2].Embedding), 1e-5)
+}
+
+func TestShouldRunToolsAgainstScript(t *testing.T) {
+	// given
+	provider := NewScripted(Script{Chat: []ScriptedReply{
+		{Match: "some question", Call: &openai.FunctionCall{Name: "someTool", Arguments: `{}`}},
+		{Match: "some result", Content: "some answer"},
+	}})
+	sut := NewChat(provider)
+	tools := NewTools()
+	Register(tools, "someTool", "some description", func(ctx context.Context, params struct{}) (string, error) {
+		return "some result", nil
+	})
+
+	// when
+	trace, err := sut.RunTools(context.Background(), NewConversation("").AddUser("some question"), tools)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some answer", trace.Answer)
+	assert.Len(t, trace.Steps, 1)
+	assert.Len(t, provider.Requests(), 2)
+}
+
+func TestShouldFlagScriptedEntries(t *testing.T) {
+	// given
+	sut := NewModerator(NewScripted(Script{Flagged: []string{"bad"}}))
+
+	// when
+	flagged, err := sut.Moderate(context.Background(), "something bad")
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, flagged)
+}
@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// StreamFunc receives reply fragments as soon as they arrive, returning an error stops the stream
+type StreamFunc func(delta string) error
+
+// StreamResult is the whole streamed reply
+type StreamResult struct {
+	Content      string
+	FinishReason openai.FinishReason
+}
+
+// Truncated tells whether the reply was cut off by the token limit
+func (r StreamResult) Truncated() bool {
+	return r.FinishReason == openai.FinishReasonLength
+}
+
+type RequestOption func(*openai.ChatCompletionRequest)
+
+// WithMaxTokens limits the length of the reply
+func WithMaxTokens(n int) RequestOption {
+	return func(r *openai.ChatCompletionRequest) {
+		r.MaxTokens = n
+	}
+}
+
+func (c *Chat) ModeratedStream(ctx context.Context, conv *Conversation, onDelta StreamFunc, opts ...RequestOption) (StreamResult, error) {
+	if err := c.moderate(ctx, conv); err != nil {
+		return StreamResult{}, err
+	}
+	res, err := c.Stream(ctx, conv, onDelta, opts...)
+	if err != nil {
+		return res, fmt.Errorf("failed to stream moderated conversation: %w", err)
+	}
+	return res, nil
+}
+
+// Stream completes the conversation passing the reply to onDelta piece by piece.
+// The whole reply is appended to the conversation once the stream finishes.
+// When the context is cancelled the reply received so far is returned along with the error.
+// A nil onDelta only collects the reply.
+func (c *Chat) Stream(ctx context.Context, conv *Conversation, onDelta StreamFunc, opts ...RequestOption) (StreamResult, error) {
+	if onDelta == nil {
+		onDelta = func(string) error { return nil }
+	}
+	req := newRequest(c.model, conv)
+	for _, o := range opts {
+		o(&req)
+	}
+	req.Stream = true
+	if err := c.fitBudget(ctx, &req); err != nil {
+		return StreamResult{}, fmt.Errorf("failed to fit prompt into budget: %w", err)
+	}
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return StreamResult{}, fmt.Errorf("response failure for chat completion stream: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var res StreamResult
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			res.Content = content.String()
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return res, ctxErr
+			}
+			return res, fmt.Errorf("failed to receive chat completion stream: %v", err)
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		choice := resp.Choices[0]
+		if choice.FinishReason != "" {
+			res.FinishReason = choice.FinishReason
+		}
+		if choice.Delta.Content == "" {
+			continue
+		}
+		content.WriteString(choice.Delta.Content)
+		if err := onDelta(choice.Delta.Content); err != nil {
+			res.Content = content.String()
+			return res, fmt.Errorf("stream interrupted: %w", err)
+		}
+	}
+	res.Content = content.String()
+	conv.AddAssistant(res.Content)
+	return res, nil
+}
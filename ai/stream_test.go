@@ -0,0 +1,99 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldStreamDeltasAndReportFinishReason(t *testing.T) {
+	// given
+	sut, req := newStreamingChat(t, openai.FinishReasonLength, "some ", "long ", "answer")
+	conv := NewConversation("some system").AddUser("some question")
+	var deltas []string
+
+	// when
+	res, err := sut.Stream(context.Background(), conv, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	}, WithMaxTokens(3))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some ", "long ", "answer"}, deltas)
+	assert.Equal(t, "some long answer", res.Content)
+	assert.True(t, res.Truncated())
+	assert.True(t, req.Stream)
+	assert.Equal(t, 3, req.MaxTokens)
+	last, _ := conv.Last()
+	assert.Equal(t, "some long answer", last.Content)
+}
+
+func TestShouldStopStreamingWhenCallbackFails(t *testing.T) {
+	// given
+	sut, _ := newStreamingChat(t, openai.FinishReasonStop, "some ", "answer")
+	conv := NewConversation("some system").AddUser("some question")
+	someErr := errors.New("some error")
+
+	// when
+	res, err := sut.Stream(context.Background(), conv, func(delta string) error {
+		return someErr
+	})
+
+	// then
+	assert.ErrorIs(t, err, someErr)
+	assert.Equal(t, "some ", res.Content)
+	assert.Equal(t, 2, conv.Len())
+}
+
+func TestShouldStreamWithoutCallback(t *testing.T) {
+	// given
+	sut, _ := newStreamingChat(t, openai.FinishReasonStop, "some ", "answer")
+	conv := NewConversation("some system").AddUser("some question")
+
+	// when
+	res, err := sut.Stream(context.Background(), conv, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some answer", res.Content)
+}
+
+// newStreamingChat creates a chat talking to a fake OpenAI server which streams the given deltas
+func newStreamingChat(t *testing.T, finishReason openai.FinishReason, deltas ...string) (*Chat, *openai.ChatCompletionRequest) {
+	var received openai.ChatCompletionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, delta := range deltas {
+			chunk := openai.ChatCompletionStreamResponse{
+				Choices: []openai.ChatCompletionStreamChoice{{
+					Delta: openai.ChatCompletionStreamChoiceDelta{Content: delta},
+				}},
+			}
+			if i == len(deltas)-1 {
+				chunk.Choices[0].FinishReason = finishReason
+			}
+			bb, _ := json.Marshal(chunk)
+			fmt.Fprintf(w, "data: %s\n\n", bb)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	cfg := openai.DefaultConfig("some key")
+	cfg.BaseURL = srv.URL
+	return &Chat{
+		client: NewOpenAIWithConfig(cfg),
+		model:  openai.GPT3Dot5Turbo,
+	}, &received
+}
@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultMaxAttempts = 3
+	respondFuncName    = "respond"
+)
+
+var (
+	ErrInvalidOutput = errors.New("model did not produce valid output")
+)
+
+// Validator is implemented by targets which need more checks than the JSON schema provides
+type Validator interface {
+	Validate() error
+}
+
+type StructuredOption func(*structuredOptions)
+
+// WithMaxAttempts limits how many times the model is asked to fix invalid output
+func WithMaxAttempts(n int) StructuredOption {
+	return func(o *structuredOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithJSONMode asks for a JSON object in the message content instead of forcing a function call
+func WithJSONMode() StructuredOption {
+	return func(o *structuredOptions) {
+		o.jsonMode = true
+	}
+}
+
+type structuredOptions struct {
+	maxAttempts int
+	jsonMode    bool
+}
+
+func (c *Chat) ModeratedCompleteInto(ctx context.Context, conv *Conversation, target any, options ...StructuredOption) error {
+	if err := c.moderate(ctx, conv); err != nil {
+		return err
+	}
+	if err := c.CompleteInto(ctx, conv, target, options...); err != nil {
+		return fmt.Errorf("failed to complete moderated conversation into %T: %w", target, err)
+	}
+	return nil
+}
+
+// CompleteInto decodes the model reply into the target which must be a pointer to a struct.
+// The reply is validated against the schema derived from the target and its Validate method,
+// the model is asked to correct invalid replies until it runs out of attempts.
+// Only the accepted reply is appended to the conversation.
+func (c *Chat) CompleteInto(ctx context.Context, conv *Conversation, target any, options ...StructuredOption) error {
+	opts := &structuredOptions{
+		maxAttempts: defaultMaxAttempts,
+	}
+	for _, o := range options {
+		o(opts)
+	}
+	if v := reflect.ValueOf(target); v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("target should be a non nil pointer, not %T", target)
+	}
+	schema, err := Schema(target)
+	if err != nil {
+		return fmt.Errorf("failed to derive schema: %v", err)
+	}
+
+	attempts := conv.Clone()
+	if opts.jsonMode {
+		schemaJSON, err := json.Marshal(schema)
+		if err != nil {
+			return fmt.Errorf("failed to encode schema: %v", err)
+		}
+		attempts.AddSystem(fmt.Sprintf("Reply with a JSON object matching the following JSON schema:\n%s", schemaJSON))
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < opts.maxAttempts; attempt++ {
+		req := newRequest(c.model, attempts)
+		if opts.jsonMode {
+			req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			}
+		} else {
+			req.Tools = []openai.Tool{respondTool(schema)}
+			req.ToolChoice = openai.ToolChoice{
+				Type:     openai.ToolTypeFunction,
+				Function: openai.ToolFunction{Name: respondFuncName},
+			}
+		}
+		msg, err := c.complete(ctx, req)
+		if err != nil {
+			return err
+		}
+		attempts.Add(msg)
+
+		output := msg.Content
+		if !opts.jsonMode {
+			if len(msg.ToolCalls) == 0 {
+				lastErr = fmt.Errorf("no %s function was called", respondFuncName)
+				attempts.AddUser(fmt.Sprintf("You must call the %s function.", respondFuncName))
+				continue
+			}
+			output = msg.ToolCalls[0].Function.Arguments
+		}
+
+		lastErr = decodeInto(output, schema, target)
+		if lastErr == nil {
+			conv.AddAssistant(output)
+			return nil
+		}
+		log.Printf("invalid structured output %s: %v", output, lastErr)
+		correction := fmt.Sprintf("Your reply is invalid: %v. Fix it and reply again.", lastErr)
+		if opts.jsonMode {
+			attempts.AddUser(correction)
+			continue
+		}
+		// every call needs its result or the next request is rejected, only the first one is taken as the reply
+		for i, call := range msg.ToolCalls {
+			result := correction
+			if i > 0 {
+				result = fmt.Sprintf("Ignored, call %s once only.", respondFuncName)
+			}
+			attempts.AddToolResult(call.ID, call.Function.Name, result)
+		}
+	}
+	return fmt.Errorf("%w after %d attempts: %v", ErrInvalidOutput, opts.maxAttempts, lastErr)
+}
+
+func respondTool(schema Definition) openai.Tool {
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: openai.FunctionDefinition{
+			Name:        respondFuncName,
+			Description: "Respond with the structured answer",
+			Parameters:  schema,
+		},
+	}
+}
+
+func decodeInto(output string, schema Definition, target any) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(output), &fields); err != nil {
+		return fmt.Errorf("reply is not a JSON object: %v", err)
+	}
+	for _, name := range schema.Required {
+		if value, exist := fields[name]; !exist || string(value) == "null" {
+			return fmt.Errorf("required field %s is missing", name)
+		}
+	}
+	for name, prop := range schema.Properties {
+		value, exist := fields[name]
+		if len(prop.Enum) == 0 || !exist {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil || !slices.Contains(prop.Enum, s) {
+			return fmt.Errorf("field %s should be one of %v, not %s", name, prop.Enum, value)
+		}
+	}
+	v := reflect.ValueOf(target).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := json.Unmarshal([]byte(output), target); err != nil {
+		return fmt.Errorf("reply does not match the schema: %v", err)
+	}
+	if validator, ok := target.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
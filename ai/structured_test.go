@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type someAnswer struct {
+	Kind  string `json:"kind" enum:"a,b"`
+	Value int    `json:"value"`
+}
+
+func (a *someAnswer) Validate() error {
+	if a.Value < 0 {
+		return errors.New("value should not be negative")
+	}
+	return nil
+}
+
+func respondCall(id, args string) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{
+			{ID: id, Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: respondFuncName, Arguments: args}},
+		},
+	}
+}
+
+func TestShouldCompleteIntoStructWithForcedFunction(t *testing.T) {
+	// given
+	sut, requests := newScriptedChat(t, respondCall("call_1", `{"kind":"a","value":3}`))
+	conv := NewConversation("some system").AddUser("some question")
+	var answer someAnswer
+
+	// when
+	err := sut.CompleteInto(context.Background(), conv, &answer)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, someAnswer{Kind: "a", Value: 3}, answer)
+	assert.Equal(t, 3, conv.Len())
+	assert.Equal(t, respondFuncName, (*requests)[0].ToolChoice.(map[string]any)["function"].(map[string]any)["name"])
+}
+
+func TestShouldRepromptWithValidationErrors(t *testing.T) {
+	// given
+	sut, requests := newScriptedChat(t,
+		respondCall("call_1", `{"value":3}`),
+		respondCall("call_2", `{"kind":"c","value":3}`),
+		respondCall("call_3", `{"kind":"b","value":-1}`),
+		respondCall("call_4", `{"kind":"b","value":1}`),
+	)
+	conv := NewConversation("some system").AddUser("some question")
+	var answer someAnswer
+
+	// when
+	err := sut.CompleteInto(context.Background(), conv, &answer, WithMaxAttempts(4))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, someAnswer{Kind: "b", Value: 1}, answer)
+	assert.Len(t, *requests, 4)
+	last := (*requests)[3].Messages
+	assert.Contains(t, last[3].Content, "required field kind is missing")
+	assert.Contains(t, last[5].Content, "should be one of")
+	assert.Contains(t, last[7].Content, "value should not be negative")
+	assert.Equal(t, 3, conv.Len())
+}
+
+func TestShouldAnswerEveryToolCallWhenCorrecting(t *testing.T) {
+	// given
+	parallel := respondCall("call_1", `{"kind":"c","value":3}`)
+	parallel.ToolCalls = append(parallel.ToolCalls, respondCall("call_2", `{"kind":"a","value":1}`).ToolCalls...)
+	sut, requests := newScriptedChat(t, parallel, respondCall("call_3", `{"kind":"a","value":3}`))
+	conv := NewConversation("some system").AddUser("some question")
+	var answer someAnswer
+
+	// when
+	err := sut.CompleteInto(context.Background(), conv, &answer, WithMaxAttempts(2))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, someAnswer{Kind: "a", Value: 3}, answer)
+	require.Len(t, *requests, 2)
+	retry := (*requests)[1].Messages
+	require.Len(t, retry, 5)
+	assert.Equal(t, "call_1", retry[3].ToolCallID)
+	assert.Contains(t, retry[3].Content, "should be one of")
+	assert.Equal(t, "call_2", retry[4].ToolCallID)
+	assert.Contains(t, retry[4].Content, "Ignored")
+}
+
+func TestShouldFailWhenOutputIsStillInvalid(t *testing.T) {
+	// given
+	sut, _ := newScriptedChat(t,
+		respondCall("call_1", `not a json`),
+		respondCall("call_2", `{"kind":"a"}`),
+	)
+	conv := NewConversation("some system").AddUser("some question")
+	var answer someAnswer
+
+	// when
+	err := sut.CompleteInto(context.Background(), conv, &answer, WithMaxAttempts(2))
+
+	// then
+	assert.ErrorIs(t, err, ErrInvalidOutput)
+	assert.Equal(t, 2, conv.Len())
+}
+
+func TestShouldCompleteIntoStructInJSONMode(t *testing.T) {
+	// given
+	sut, requests := newScriptedChat(t, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: `{"kind":"b","value":2}`,
+	})
+	conv := NewConversation("some system").AddUser("some question")
+	var answer someAnswer
+
+	// when
+	err := sut.CompleteInto(context.Background(), conv, &answer, WithJSONMode())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, someAnswer{Kind: "b", Value: 2}, answer)
+	assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONObject, (*requests)[0].ResponseFormat.Type)
+	assert.Empty(t, (*requests)[0].Tools)
+}
@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultEncoding      = "cl100k_base"
+	defaultContextWindow = 4096
+	// every message is wrapped in <|start|>{role/name}\n{content}<|end|>\n
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	// every reply is primed with <|start|>assistant<|message|>
+	tokensPerReply = 3
+	// low detail image cost, high detail depends on the image size which is unknown here
+	tokensPerImage = 85
+)
+
+var (
+	contextWindows = map[string]int{
+		openai.GPT3Dot5Turbo:        4096,
+		openai.GPT3Dot5Turbo0301:    4096,
+		openai.GPT3Dot5Turbo0613:    4096,
+		openai.GPT3Dot5Turbo1106:    16385,
+		openai.GPT3Dot5Turbo16K:     16385,
+		openai.GPT3Dot5Turbo16K0613: 16385,
+		openai.GPT4:                 8192,
+		openai.GPT40314:             8192,
+		openai.GPT40613:             8192,
+		openai.GPT432K:              32768,
+		openai.GPT432K0314:          32768,
+		openai.GPT432K0613:          32768,
+		openai.GPT4TurboPreview:     128000,
+		openai.GPT4VisionPreview:    128000,
+	}
+
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]*tiktoken.Tiktoken)
+)
+
+func init() {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// ContextWindow returns how many tokens the model accepts for the prompt and the reply together
+func ContextWindow(model string) int {
+	if window, exist := contextWindows[model]; exist {
+		return window
+	}
+	return defaultContextWindow
+}
+
+// CountTokens returns how many prompt tokens the messages take when sent to the model
+func CountTokens(model string, msgs []openai.ChatCompletionMessage) (int, error) {
+	enc, err := encoding(model)
+	if err != nil {
+		return 0, err
+	}
+	tokens := tokensPerReply
+	for _, msg := range msgs {
+		tokens += countMessageTokens(enc, msg)
+	}
+	return tokens, nil
+}
+
+// CountTextTokens returns how many tokens the text takes for the model
+func CountTextTokens(model, text string) (int, error) {
+	enc, err := encoding(model)
+	if err != nil {
+		return 0, err
+	}
+	return len(enc.EncodeOrdinary(text)), nil
+}
+
+// TruncateText cuts the text down to the given number of tokens
+func TruncateText(model, text string, maxTokens int) (string, error) {
+	enc, err := encoding(model)
+	if err != nil {
+		return "", err
+	}
+	tokens := enc.EncodeOrdinary(text)
+	if len(tokens) <= maxTokens {
+		return text, nil
+	}
+	if maxTokens <= 0 {
+		return "", nil
+	}
+	return enc.Decode(tokens[:maxTokens]), nil
+}
+
+func countMessageTokens(enc *tiktoken.Tiktoken, msg openai.ChatCompletionMessage) int {
+	tokens := tokensPerMessage + len(enc.EncodeOrdinary(msg.Role)) + len(enc.EncodeOrdinary(msg.Content))
+	if msg.Name != "" {
+		tokens += tokensPerName + len(enc.EncodeOrdinary(msg.Name))
+	}
+	for _, part := range msg.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			tokens += len(enc.EncodeOrdinary(part.Text))
+		case openai.ChatMessagePartTypeImageURL:
+			tokens += tokensPerImage
+		}
+	}
+	if msg.FunctionCall != nil {
+		tokens += len(enc.EncodeOrdinary(msg.FunctionCall.Name + msg.FunctionCall.Arguments))
+	}
+	for _, call := range msg.ToolCalls {
+		tokens += len(enc.EncodeOrdinary(call.Function.Name + call.Function.Arguments))
+	}
+	return tokens
+}
+
+func encoding(model string) (*tiktoken.Tiktoken, error) {
+	name := defaultEncoding
+	if modelEncoding, exist := tiktoken.MODEL_TO_ENCODING[model]; exist {
+		name = modelEncoding
+	} else {
+		for prefix, prefixEncoding := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+			if strings.HasPrefix(model, prefix) {
+				name = prefixEncoding
+				break
+			}
+		}
+	}
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if enc, exist := encodings[name]; exist {
+		return enc, nil
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s tokenizer: %v", name, err)
+	}
+	encodings[name] = enc
+	return enc, nil
+}
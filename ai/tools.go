@@ -0,0 +1,165 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultMaxSteps = 5
+)
+
+var (
+	ErrMaxSteps = errors.New("tool calling did not finish within the step limit")
+)
+
+// Tools is a registry of Go functions the model is allowed to call
+type Tools struct {
+	tools map[string]tool
+	names []string
+}
+
+type tool struct {
+	definition openai.FunctionDefinition
+	call       func(ctx context.Context, args string) (string, error)
+}
+
+func NewTools() *Tools {
+	return &Tools{
+		tools: make(map[string]tool),
+	}
+}
+
+// Register adds the function to the registry, the parameters schema is derived from the P struct
+func Register[P any](t *Tools, name, description string, fn func(ctx context.Context, params P) (string, error)) error {
+	if _, exist := t.tools[name]; exist {
+		return fmt.Errorf("tool %s already registered", name)
+	}
+	var params P
+	schema, err := Schema(params)
+	if err != nil {
+		return fmt.Errorf("failed to derive schema of tool %s: %v", name, err)
+	}
+	t.tools[name] = tool{
+		definition: openai.FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  schema,
+		},
+		call: func(ctx context.Context, args string) (string, error) {
+			var params P
+			if err := json.Unmarshal([]byte(args), &params); err != nil {
+				return "", fmt.Errorf("failed to decode params json '%s': %v", args, err)
+			}
+			return fn(ctx, params)
+		},
+	}
+	t.names = append(t.names, name)
+	return nil
+}
+
+// Definitions returns tools in the order they were registered
+func (t *Tools) Definitions() []openai.Tool {
+	defs := make([]openai.Tool, 0, len(t.names))
+	for _, name := range t.names {
+		defs = append(defs, openai.Tool{
+			Type:     openai.ToolTypeFunction,
+			Function: t.tools[name].definition,
+		})
+	}
+	return defs
+}
+
+// Call executes the tool with arguments encoded as JSON
+func (t *Tools) Call(ctx context.Context, name, args string) (string, error) {
+	tool, exist := t.tools[name]
+	if !exist {
+		return "", fmt.Errorf("unsupported tool %s", name)
+	}
+	log.Printf("calling tool %s with params %s", name, args)
+	return tool.call(ctx, args)
+}
+
+// ToolStep is a single tool call made on the model request
+type ToolStep struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result,omitempty"`
+	Err       string `json:"error,omitempty"`
+}
+
+// ToolTrace describes the whole model - tool - model loop
+type ToolTrace struct {
+	Steps  []ToolStep `json:"steps"`
+	Answer string     `json:"answer"`
+}
+
+type RunOption func(*runOptions)
+
+// WithMaxSteps limits how many times the model is asked before the final answer is given
+func WithMaxSteps(n int) RunOption {
+	return func(o *runOptions) {
+		o.maxSteps = n
+	}
+}
+
+type runOptions struct {
+	maxSteps int
+}
+
+func (c *Chat) ModeratedRunTools(ctx context.Context, conv *Conversation, tools *Tools, options ...RunOption) (ToolTrace, error) {
+	if err := c.moderate(ctx, conv); err != nil {
+		return ToolTrace{}, err
+	}
+	trace, err := c.RunTools(ctx, conv, tools, options...)
+	if err != nil {
+		return trace, fmt.Errorf("failed to run moderated tools: %w", err)
+	}
+	return trace, nil
+}
+
+// RunTools lets the model call tools until it gives the final answer.
+// Tool failures are passed back to the model so it can correct the call.
+func (c *Chat) RunTools(ctx context.Context, conv *Conversation, tools *Tools, options ...RunOption) (ToolTrace, error) {
+	opts := &runOptions{
+		maxSteps: defaultMaxSteps,
+	}
+	for _, o := range options {
+		o(opts)
+	}
+	var trace ToolTrace
+	for step := 0; step < opts.maxSteps; step++ {
+		req := newRequest(c.model, conv)
+		req.Tools = tools.Definitions()
+		msg, err := c.complete(ctx, req)
+		if err != nil {
+			return trace, err
+		}
+		conv.Add(msg)
+		if len(msg.ToolCalls) == 0 {
+			trace.Answer = msg.Content
+			return trace, nil
+		}
+		for _, call := range msg.ToolCalls {
+			toolStep := ToolStep{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			}
+			result, err := tools.Call(ctx, call.Function.Name, call.Function.Arguments)
+			if err != nil {
+				toolStep.Err = err.Error()
+				result = fmt.Sprintf("error: %v", err)
+			} else {
+				toolStep.Result = result
+			}
+			trace.Steps = append(trace.Steps, toolStep)
+			conv.AddToolResult(call.ID, call.Function.Name, result)
+		}
+	}
+	return trace, fmt.Errorf("%w: %d", ErrMaxSteps, opts.maxSteps)
+}
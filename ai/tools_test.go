@@ -0,0 +1,228 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+type someParams struct {
+	Name     string   `json:"name" description:"some name"`
+	Kind     string   `json:"kind" enum:"a,b"`
+	Count    int      `json:"count,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Optional *float64 `json:"optional"`
+	Ignored  string   `json:"-"`
+}
+
+func TestShouldDeriveSchemaFromStructTags(t *testing.T) {
+	// when
+	schema, err := Schema(someParams{})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, jsonschema.Object, schema.Type)
+	assert.Equal(t, []string{"name", "kind"}, schema.Required)
+	assert.Len(t, schema.Properties, 5)
+	assert.Equal(t, "some name", schema.Properties["name"].Description)
+	assert.Equal(t, []string{"a", "b"}, schema.Properties["kind"].Enum)
+	assert.Equal(t, jsonschema.Integer, schema.Properties["count"].Type)
+	assert.Equal(t, jsonschema.String, schema.Properties["tags"].Items.Type)
+	assert.Equal(t, jsonschema.Number, schema.Properties["optional"].Type)
+}
+
+type node struct {
+	Name     string `json:"name"`
+	Children []node `json:"children"`
+}
+
+type event struct {
+	At    time.Time  `json:"at"`
+	Until *time.Time `json:"until"`
+}
+
+func TestShouldDescribeTimeAsDateTimeString(t *testing.T) {
+	// when
+	schema, err := Schema(event{})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, Definition{Type: jsonschema.String, Format: "date-time"}, schema.Properties["at"])
+	assert.Equal(t, Definition{Type: jsonschema.String, Format: "date-time"}, schema.Properties["until"])
+	bb, err := json.Marshal(schema.Properties["at"])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"string","format":"date-time"}`, string(bb))
+}
+
+func TestShouldRejectRecursiveSchema(t *testing.T) {
+	// when
+	_, err := Schema(node{})
+
+	// then
+	assert.ErrorContains(t, err, "recursive type ai.node")
+}
+
+func TestShouldWritePropertiesOfEmptyObject(t *testing.T) {
+	// given
+	schema, err := Schema(struct{}{})
+	assert.NoError(t, err)
+
+	// when
+	bb, err := json.Marshal(schema)
+
+	// then
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{}}`, string(bb))
+}
+
+func TestShouldNotDeriveSchemaFromNonStruct(t *testing.T) {
+	// when
+	_, err := Schema("some string")
+
+	// then
+	assert.Error(t, err)
+}
+
+func TestShouldNotRegisterToolTwice(t *testing.T) {
+	// given
+	sut := NewTools()
+	fn := func(ctx context.Context, params someParams) (string, error) { return "", nil }
+	assert.NoError(t, Register(sut, "someTool", "some description", fn))
+
+	// when
+	err := Register(sut, "someTool", "some description", fn)
+
+	// then
+	assert.Error(t, err)
+	assert.Len(t, sut.Definitions(), 1)
+}
+
+func TestShouldCallToolWithDecodedParams(t *testing.T) {
+	// given
+	sut := NewTools()
+	var received someParams
+	Register(sut, "someTool", "some description", func(ctx context.Context, params someParams) (string, error) {
+		received = params
+		return "some result", nil
+	})
+
+	// when
+	result, err := sut.Call(context.Background(), "someTool", `{"name":"some name","count":3}`)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some result", result)
+	assert.Equal(t, someParams{Name: "some name", Count: 3}, received)
+}
+
+func TestShouldReturnErrorWhenToolIsUnknown(t *testing.T) {
+	// given
+	sut := NewTools()
+
+	// when
+	_, err := sut.Call(context.Background(), "unknown", `{}`)
+
+	// then
+	assert.Error(t, err)
+}
+
+func TestShouldRunToolsUntilFinalAnswer(t *testing.T) {
+	// given
+	toolCall := openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{
+			{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "someTool", Arguments: `{"name":"x","kind":"a"}`}},
+			{ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "failingTool", Arguments: `{}`}},
+		},
+	}
+	answer := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: "some answer",
+	}
+	sut, requests := newScriptedChat(t, toolCall, answer)
+	tools := NewTools()
+	Register(tools, "someTool", "some description", func(ctx context.Context, params someParams) (string, error) {
+		return "some result", nil
+	})
+	Register(tools, "failingTool", "some description", func(ctx context.Context, params struct{}) (string, error) {
+		return "", errors.New("some error")
+	})
+	conv := NewConversation("some system").AddUser("some question")
+
+	// when
+	trace, err := sut.RunTools(context.Background(), conv, tools)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some answer", trace.Answer)
+	assert.Equal(t, []ToolStep{
+		{Name: "someTool", Arguments: `{"name":"x","kind":"a"}`, Result: "some result"},
+		{Name: "failingTool", Arguments: `{}`, Err: "some error"},
+	}, trace.Steps)
+	assert.Equal(t, 6, conv.Len())
+	assert.Len(t, *requests, 2)
+	assert.Len(t, (*requests)[0].Tools, 2)
+	assert.Equal(t, "call_2", (*requests)[1].Messages[4].ToolCallID)
+	assert.Equal(t, "error: some error", (*requests)[1].Messages[4].Content)
+}
+
+func TestShouldStopRunningToolsAfterMaxSteps(t *testing.T) {
+	// given
+	toolCall := openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{
+			{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "someTool", Arguments: `{}`}},
+		},
+	}
+	sut, requests := newScriptedChat(t, toolCall, toolCall, toolCall)
+	tools := NewTools()
+	Register(tools, "someTool", "some description", func(ctx context.Context, params struct{}) (string, error) {
+		return "some result", nil
+	})
+
+	// when
+	trace, err := sut.RunTools(context.Background(), NewConversation("some system"), tools, WithMaxSteps(2))
+
+	// then
+	assert.ErrorIs(t, err, ErrMaxSteps)
+	assert.Len(t, trace.Steps, 2)
+	assert.Len(t, *requests, 2)
+}
+
+// newScriptedChat creates a chat talking to a fake OpenAI server which replies with the given messages in order
+func newScriptedChat(t *testing.T, replies ...openai.ChatCompletionMessage) (*Chat, *[]openai.ChatCompletionRequest) {
+	var requests []openai.ChatCompletionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if len(requests) >= len(replies) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		reply := replies[len(requests)]
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: reply}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	cfg := openai.DefaultConfig("some key")
+	cfg.BaseURL = srv.URL
+	client := NewOpenAIWithConfig(cfg)
+	return &Chat{
+		client:    client,
+		moderator: &Moderator{client: client},
+		model:     openai.GPT3Dot5Turbo,
+	}, &requests
+}
@@ -0,0 +1,291 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	KindChat          = "chat"
+	KindEmbedding     = "embedding"
+	KindModeration    = "moderation"
+	KindTranscription = "transcription"
+)
+
+// Price is the USD cost of thousand tokens, transcriptions are priced per minute of audio
+type Price struct {
+	Prompt     float64
+	Completion float64
+	PerMinute  float64
+}
+
+var (
+	// prices are matched by the longest model name prefix
+	prices = map[string]Price{
+		"gpt-3.5-turbo":          {Prompt: 0.0015, Completion: 0.002},
+		"gpt-3.5-turbo-1106":     {Prompt: 0.001, Completion: 0.002},
+		"gpt-3.5-turbo-16k":      {Prompt: 0.003, Completion: 0.004},
+		"gpt-4":                  {Prompt: 0.03, Completion: 0.06},
+		"gpt-4-32k":              {Prompt: 0.06, Completion: 0.12},
+		"gpt-4-1106-preview":     {Prompt: 0.01, Completion: 0.03},
+		"gpt-4-vision-preview":   {Prompt: 0.01, Completion: 0.03},
+		"text-embedding-ada-002": {Prompt: 0.0001},
+		"text-moderation":        {},
+		"whisper-1":              {PerMinute: 0.006},
+	}
+)
+
+// PriceOf returns the price of the model and false when the model is unknown
+func PriceOf(model string) (Price, bool) {
+	var best string
+	for prefix := range prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return prices[best], true
+}
+
+// Usage describes a single call to the provider
+type Usage struct {
+	Kind             string  `json:"kind"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	AudioSeconds     float64 `json:"audioSeconds,omitempty"`
+	Cost             float64 `json:"cost"`
+	// Estimated is set when the provider did not report tokens and they were counted locally
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// ModelUsage aggregates calls made to a single model
+type ModelUsage struct {
+	Model            string  `json:"model"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// Ledger records usage of all calls made during a run
+type Ledger struct {
+	mu      sync.Mutex
+	entries []Usage
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{}
+}
+
+// Record prices the usage and stores it
+func (l *Ledger) Record(u Usage) {
+	price, _ := PriceOf(u.Model)
+	u.Cost = float64(u.PromptTokens)/1000*price.Prompt +
+		float64(u.CompletionTokens)/1000*price.Completion +
+		u.AudioSeconds/60*price.PerMinute
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, u)
+}
+
+func (l *Ledger) Entries() []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Usage(nil), l.entries...)
+}
+
+// Summary aggregates usage per model, sorted by model name
+func (l *Ledger) Summary() []ModelUsage {
+	byModel := make(map[string]*ModelUsage)
+	for _, u := range l.Entries() {
+		m, exist := byModel[u.Model]
+		if !exist {
+			m = &ModelUsage{Model: u.Model}
+			byModel[u.Model] = m
+		}
+		m.Calls++
+		m.PromptTokens += u.PromptTokens
+		m.CompletionTokens += u.CompletionTokens
+		m.Cost += u.Cost
+	}
+	summary := make([]ModelUsage, 0, len(byModel))
+	for _, m := range byModel {
+		summary = append(summary, *m)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Model < summary[j].Model
+	})
+	return summary
+}
+
+// Cost returns the total cost of all calls
+func (l *Ledger) Cost() float64 {
+	var cost float64
+	for _, u := range l.Entries() {
+		cost += u.Cost
+	}
+	return cost
+}
+
+// WriteSummary prints usage per model as a table
+func (l *Ledger) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tCALLS\tPROMPT\tCOMPLETION\tCOST")
+	var total ModelUsage
+	for _, m := range l.Summary() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t$%.4f\n", m.Model, m.Calls, m.PromptTokens, m.CompletionTokens, m.Cost)
+		total.Calls += m.Calls
+		total.PromptTokens += m.PromptTokens
+		total.CompletionTokens += m.CompletionTokens
+		total.Cost += m.Cost
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\t$%.4f\n", total.Calls, total.PromptTokens, total.CompletionTokens, total.Cost)
+	return tw.Flush()
+}
+
+// Metered is a provider recording usage of every successful call in the ledger
+type Metered struct {
+	provider Provider
+	ledger   *Ledger
+}
+
+func NewMetered(provider Provider, ledger *Ledger) *Metered {
+	return &Metered{
+		provider: provider,
+		ledger:   ledger,
+	}
+}
+
+func (m *Metered) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	resp, err := m.provider.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	usage := Usage{
+		Kind:             KindChat,
+		Model:            modelOr(resp.Model, req.Model),
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+	if resp.Usage.TotalTokens == 0 {
+		usage.PromptTokens, _ = CountTokens(req.Model, req.Messages)
+		for _, choice := range resp.Choices {
+			usage.CompletionTokens += messageTokens(choice.Message) - tokensPerMessage
+		}
+		usage.Estimated = true
+	}
+	m.ledger.Record(usage)
+	return resp, nil
+}
+
+func (m *Metered) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (ChatStream, error) {
+	stream, err := m.provider.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	promptTokens, _ := CountTokens(req.Model, req.Messages)
+	return &meteredStream{
+		stream: stream,
+		ledger: m.ledger,
+		usage: Usage{
+			Kind:         KindChat,
+			Model:        req.Model,
+			PromptTokens: promptTokens,
+			Estimated:    true,
+		},
+	}, nil
+}
+
+func (m *Metered) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	resp, err := m.provider.CreateEmbeddings(ctx, conv)
+	if err != nil {
+		return resp, err
+	}
+	m.ledger.Record(Usage{
+		Kind:         KindEmbedding,
+		Model:        modelOr(string(resp.Model), string(conv.Convert().Model)),
+		PromptTokens: resp.Usage.PromptTokens,
+	})
+	return resp, nil
+}
+
+func (m *Metered) Moderations(ctx context.Context, req openai.ModerationRequest) (openai.ModerationResponse, error) {
+	resp, err := m.provider.Moderations(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	m.ledger.Record(Usage{
+		Kind:  KindModeration,
+		Model: modelOr(resp.Model, req.Model),
+	})
+	return resp, nil
+}
+
+func (m *Metered) CreateTranscription(ctx context.Context, req openai.AudioRequest) (openai.AudioResponse, error) {
+	resp, err := m.provider.CreateTranscription(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	m.ledger.Record(Usage{
+		Kind:         KindTranscription,
+		Model:        req.Model,
+		AudioSeconds: resp.Duration,
+	})
+	return resp, nil
+}
+
+func modelOr(model, fallback string) string {
+	if model != "" {
+		return model
+	}
+	return fallback
+}
+
+// meteredStream counts streamed content and records the usage once the stream is finished
+type meteredStream struct {
+	stream   ChatStream
+	ledger   *Ledger
+	usage    Usage
+	content  strings.Builder
+	recorded bool
+}
+
+func (s *meteredStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	resp, err := s.stream.Recv()
+	if errors.Is(err, io.EOF) {
+		s.record()
+	}
+	if err != nil {
+		return resp, err
+	}
+	for _, choice := range resp.Choices {
+		s.content.WriteString(choice.Delta.Content)
+	}
+	return resp, nil
+}
+
+func (s *meteredStream) Close() {
+	s.record()
+	s.stream.Close()
+}
+
+// record is called on both EOF and Close, streams closed early are still paid for
+func (s *meteredStream) record() {
+	if s.recorded {
+		return
+	}
+	s.recorded = true
+	s.usage.CompletionTokens, _ = CountTextTokens(s.usage.Model, s.content.String())
+	s.ledger.Record(s.usage)
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldPriceModelsByLongestPrefix(t *testing.T) {
+	// when
+	gpt4, known := PriceOf(openai.GPT40613)
+	turbo, _ := PriceOf(openai.GPT4TurboPreview)
+	_, unknown := PriceOf("some-model")
+
+	// then
+	assert.True(t, known)
+	assert.Equal(t, 0.03, gpt4.Prompt)
+	assert.Equal(t, 0.01, turbo.Prompt)
+	assert.False(t, unknown)
+}
+
+func TestShouldAggregateUsagePerModel(t *testing.T) {
+	// given
+	sut := NewLedger()
+
+	// when
+	sut.Record(Usage{Kind: KindChat, Model: openai.GPT4, PromptTokens: 1000, CompletionTokens: 500})
+	sut.Record(Usage{Kind: KindChat, Model: openai.GPT4, PromptTokens: 1000})
+	sut.Record(Usage{Kind: KindEmbedding, Model: string(openai.AdaEmbeddingV2), PromptTokens: 10000})
+
+	// then
+	summary := sut.Summary()
+	assert.Len(t, summary, 2)
+	assert.Equal(t, ModelUsage{Model: openai.GPT4, Calls: 2, PromptTokens: 2000, CompletionTokens: 500, Cost: 0.09}, summary[0])
+	assert.InDelta(t, 0.091, sut.Cost(), 1e-9)
+	var out bytes.Buffer
+	assert.NoError(t, sut.WriteSummary(&out))
+	assert.Contains(t, out.String(), "TOTAL")
+	assert.Contains(t, out.String(), "$0.0910")
+}
+
+func TestShouldMeterAllProviderCalls(t *testing.T) {
+	// given
+	ledger := NewLedger()
+	provider := NewMetered(NewScripted(Script{Chat: []ScriptedReply{{Content: "some answer"}}}), ledger)
+	chat := NewChat(provider)
+	ctx := context.Background()
+	embeddings := openai.EmbeddingRequest{Input: []string{"some text"}, Model: openai.AdaEmbeddingV2}
+
+	// when
+	_, err := chat.ModeratedChat(ctx, "some system", "some question")
+	assert.NoError(t, err)
+	_, err = chat.Stream(ctx, NewConversation("").AddUser("some question"), func(string) error { return nil })
+	assert.NoError(t, err)
+	_, err = provider.CreateEmbeddings(ctx, embeddings)
+	assert.NoError(t, err)
+
+	// then
+	entries := ledger.Entries()
+	kinds := make([]string, len(entries))
+	for i, u := range entries {
+		kinds[i] = u.Kind
+	}
+	assert.Equal(t, []string{KindModeration, KindChat, KindChat, KindEmbedding}, kinds)
+	assert.True(t, entries[1].Estimated)
+	assert.Greater(t, entries[1].PromptTokens, 0)
+	assert.Equal(t, 2, entries[2].CompletionTokens)
+}
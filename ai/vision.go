@@ -8,78 +8,51 @@ import (
 )
 
 type Vision struct {
-	client    *openai.Client
+	client    Provider
 	moderator *Moderator
 	model     string
 }
 
-func NewVisioner(openaiKey string) *Vision {
-	client := openai.NewClient(openaiKey)
+func NewVisioner(provider Provider) *Vision {
 	return &Vision{
-		client: client,
+		client: provider,
 		moderator: &Moderator{
-			client: client,
+			client: provider,
 		},
 		model: openai.GPT4VisionPreview,
 	}
 }
 
-func (v *Vision) ModeratedSee(system, user, assistant, imageURI string) (string, error) {
-	moderationRequired, err := v.moderator.Moderate(context.Background(), system)
+func (v *Vision) ModeratedSee(ctx context.Context, system, user, assistant, imageURI string) (string, error) {
+	moderationRequired, err := v.moderator.Moderate(ctx, system)
 	if err != nil {
 		return "", fmt.Errorf("failed to moderate entry: %v", err)
 	}
 	if moderationRequired {
 		return "", fmt.Errorf("entry breaks openai usage policies: %s", system)
 	}
-	resp, err := v.See(system, user, assistant, imageURI)
+	resp, err := v.See(ctx, system, user, assistant, imageURI)
 	if err != nil {
 		return "", fmt.Errorf("failed to complete moderated see: %v", err)
 	}
 	return resp, nil
 }
 
-func (v *Vision) See(system, user, assistant, imageURI string) (string, error) {
-	req := openai.ChatCompletionRequest{
-		Model: v.model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: system,
-			},
-			{
-				Role: openai.ChatMessageRoleUser,
-				MultiContent: []openai.ChatMessagePart{
-					{
-						Type: openai.ChatMessagePartTypeText,
-						Text: user,
-					},
-					{
-						Type: openai.ChatMessagePartTypeImageURL,
-						ImageURL: &openai.ChatMessageImageURL{
-							URL:    imageURI,
-							Detail: openai.ImageURLDetailAuto,
-						},
-					},
-				},
-			},
-			{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: assistant,
-			},
-		},
-		MaxTokens:   250,
-		Temperature: 0,
-		TopP:        1,
-		N:           1,
-		Stream:      false,
-	}
-	resp, err := v.client.CreateChatCompletion(context.Background(), req)
+func (v *Vision) See(ctx context.Context, system, user, assistant, imageURI string) (string, error) {
+	conv := NewConversation("")
+	conv.AddSystem(system).AddUserImage(user, imageURI).AddAssistant(assistant)
+	return v.Complete(ctx, conv)
+}
+
+// Complete sends the whole conversation, which may refer to images, and appends the assistant reply to it
+func (v *Vision) Complete(ctx context.Context, conv *Conversation) (string, error) {
+	resp, err := v.client.CreateChatCompletion(ctx, newRequest(v.model, conv))
 	if err != nil {
 		return "", fmt.Errorf("response failure for seeing: %v", err)
 	}
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("empty response received")
 	}
+	conv.Add(resp.Choices[0].Message)
 	return resp.Choices[0].Message.Content, nil
 }
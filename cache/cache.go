@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultTTL = 7 * 24 * time.Hour
+)
+
+// Store is a content addressed cache kept on disk, one JSON file per key
+type Store struct {
+	dir    string
+	ttl    time.Duration
+	now    func() time.Time
+	hits   atomic.Int64
+	misses atomic.Int64
+	writes atomic.Int64
+}
+
+// Stats tells how effective the cache was
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Writes int64 `json:"writes"`
+}
+
+type Option func(*Store)
+
+// WithTTL sets how long entries are valid, entries never expire when ttl is 0
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+func withClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+type entry struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// New creates the store in the given directory
+func New(dir string, opts ...Option) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %v", dir, err)
+	}
+	s := &Store{
+		dir: dir,
+		ttl: DefaultTTL,
+		now: time.Now,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
+}
+
+// DefaultDir returns the cache directory in the user cache location
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "aidevs2")
+}
+
+// Key hashes JSON encoding of all parts
+func Key(parts ...any) (string, error) {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, part := range parts {
+		if err := enc.Encode(part); err != nil {
+			return "", fmt.Errorf("failed to encode cache key part: %v", err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get decodes the value stored under the key into v, false is returned when there is no valid entry
+func (s *Store) Get(key string, v any) (bool, error) {
+	bb, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		s.misses.Add(1)
+		return false, nil
+	}
+	if err != nil {
+		s.misses.Add(1)
+		return false, fmt.Errorf("failed to read cache entry %s: %v", key, err)
+	}
+	var e entry
+	if err := json.Unmarshal(bb, &e); err != nil {
+		s.misses.Add(1)
+		return false, fmt.Errorf("failed to decode cache entry %s: %v", key, err)
+	}
+	if s.ttl > 0 && s.now().Sub(e.CreatedAt) > s.ttl {
+		s.misses.Add(1)
+		os.Remove(s.path(key))
+		return false, nil
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		s.misses.Add(1)
+		return false, fmt.Errorf("failed to decode cached value %s: %v", key, err)
+	}
+	s.hits.Add(1)
+	return true, nil
+}
+
+// Put stores the value under the key, the file is replaced atomically
+func (s *Store) Put(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached value: %v", err)
+	}
+	bb, err := json.Marshal(entry{
+		CreatedAt: s.now(),
+		Value:     value,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %v", err)
+	}
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bb); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store cache entry: %v", err)
+	}
+	s.writes.Add(1)
+	return nil
+}
+
+func (s *Store) Stats() Stats {
+	return Stats{
+		Hits:   s.hits.Load(),
+		Misses: s.misses.Load(),
+		Writes: s.writes.Load(),
+	}
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key+".json")
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type someValue struct {
+	Text string `json:"text"`
+}
+
+func TestShouldReturnStoredValue(t *testing.T) {
+	// given
+	sut, err := New(t.TempDir())
+	require.NoError(t, err)
+	key, err := Key("some kind", someValue{Text: "some request"})
+	require.NoError(t, err)
+	require.NoError(t, sut.Put(key, someValue{Text: "some response"}))
+
+	// when
+	var v someValue
+	hit, err := sut.Get(key, &v)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, "some response", v.Text)
+	assert.Equal(t, Stats{Hits: 1, Writes: 1}, sut.Stats())
+}
+
+func TestShouldMissUnknownKey(t *testing.T) {
+	// given
+	sut, err := New(t.TempDir())
+	require.NoError(t, err)
+	key, _ := Key("some request")
+
+	// when
+	var v someValue
+	hit, err := sut.Get(key, &v)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, hit)
+	assert.Equal(t, Stats{Misses: 1}, sut.Stats())
+}
+
+func TestShouldMissExpiredEntry(t *testing.T) {
+	// given
+	now := time.Now()
+	sut, err := New(t.TempDir(), WithTTL(time.Hour), withClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	key, _ := Key("some request")
+	require.NoError(t, sut.Put(key, someValue{Text: "some response"}))
+	now = now.Add(2 * time.Hour)
+
+	// when
+	var v someValue
+	hit, err := sut.Get(key, &v)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, hit)
+}
+
+func TestShouldHashDifferentRequestsToDifferentKeys(t *testing.T) {
+	// when
+	key1, _ := Key("chat", someValue{Text: "a"})
+	key2, _ := Key("chat", someValue{Text: "b"})
+	key3, _ := Key("chat", someValue{Text: "a"})
+
+	// then
+	assert.NotEqual(t, key1, key2)
+	assert.Equal(t, key1, key3)
+}
@@ -1,19 +1,84 @@
 package aidevs
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/koenno/aidevs2/resilience"
+)
+
+const (
+	// limits how much of a non JSON error body is kept in the error
+	maxErrorBody = 512
 )
 
 var (
-	httpClient = http.Client{
-		Timeout: 10 * time.Second,
-	}
+	httpClient = resilience.NewClient(30 * time.Second)
+)
+
+var (
+	ErrBadRequest         = errors.New("bad request")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrNotFound           = errors.New("not found")
+	ErrServer             = errors.New("server failure")
+	ErrUnexpectedResponse = errors.New("unexpected response")
+	ErrUnsupportedContent = errors.New("unsupported content type")
 )
 
+// Error is a non 2xx response of the AI Devs API, it matches the sentinel error of its status with errors.Is
+type Error struct {
+	Status  int
+	Code    int
+	Message string
+	Hint    string
+	// Body holds all fields of a JSON error body like note or reply, it is nil for other bodies
+	Body map[string]any
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("status %d", e.Status)
+	if e.Code != 0 {
+		msg += fmt.Sprintf(", code %d", e.Code)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Hint != "" {
+		msg += " (hint: " + e.Hint + ")"
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.Status == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrServer:
+		return e.Status >= http.StatusInternalServerError
+	case ErrUnexpectedResponse:
+		return true
+	}
+	return false
+}
+
+type errorBody struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Hint json.RawMessage `json:"hint"`
+}
+
 type Client struct {
 }
 
@@ -21,7 +86,7 @@ func (c Client) Send(r *http.Request, respPayload any) error {
 	log.Printf("sending request %s to %s", r.Method, r.URL)
 	resp, err := httpClient.Do(r)
 	if err != nil {
-		return fmt.Errorf("failed to send request %s to %s", r.Method, r.URL)
+		return fmt.Errorf("failed to send request %s to %s: %w", r.Method, r.URL, err)
 	}
 
 	defer func() {
@@ -30,10 +95,13 @@ func (c Client) Send(r *http.Request, respPayload any) error {
 			log.Printf("failed to close response body from %s", r.URL)
 		}
 	}()
-	// TODO: check status code
-	contentType := resp.Header.Get("content-type")
-	if contentType != "application/json" {
-		return fmt.Errorf("unsupported response content type from %s", r.URL)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("request %s to %s failed: %w", r.Method, r.URL, decodeError(resp))
+	}
+
+	if !isJSON(resp.Header.Get("content-type")) {
+		return fmt.Errorf("%w %q from %s", ErrUnsupportedContent, resp.Header.Get("content-type"), r.URL)
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(respPayload)
@@ -42,3 +110,55 @@ func (c Client) Send(r *http.Request, respPayload any) error {
 	}
 	return nil
 }
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeError reads the AI Devs error JSON, other bodies are kept as the message
+func decodeError(resp *http.Response) *Error {
+	e := &Error{
+		Status: resp.StatusCode,
+	}
+	bb, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		e.Message = fmt.Sprintf("failed to read error body: %v", err)
+		return e
+	}
+	var body errorBody
+	if isJSON(resp.Header.Get("content-type")) && json.Unmarshal(bb, &body) == nil {
+		e.Code = body.Code
+		e.Message = body.Msg
+		e.Hint = hintText(body.Hint)
+		if err := json.Unmarshal(bb, &e.Body); err != nil {
+			log.Printf("failed to keep error body fields: %v", err)
+		}
+		return e
+	}
+	msg := strings.TrimSpace(string(bb))
+	if len(msg) > maxErrorBody {
+		msg = msg[:maxErrorBody] + "..."
+	}
+	e.Message = msg
+	return e
+}
+
+// hintText unquotes string hints, any other JSON is returned compacted
+func hintText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
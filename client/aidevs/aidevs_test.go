@@ -27,7 +27,7 @@ func TestShouldReturnErrorWhenResponseHasUnsupportedType(t *testing.T) {
 	err = sut.Send(req, &respPayload)
 
 	// then
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrUnsupportedContent)
 }
 
 func TestShouldReturnDecodedPauload(t *testing.T) {
@@ -51,3 +51,72 @@ func TestShouldReturnDecodedPauload(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedPayload, respPayload)
 }
+
+func TestShouldAcceptJSONWithCharset(t *testing.T) {
+	// given
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("content-type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"Name":"ene due"}`))
+	}))
+	sut := Client{}
+	req, err := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	assert.NoError(t, err)
+	var respPayload TestPayload
+
+	// when
+	err = sut.Send(req, &respPayload)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "ene due", respPayload.Name)
+}
+
+func TestShouldReturnTypedErrorForErrorResponse(t *testing.T) {
+	// given
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("content-type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-777,"msg":"Answer is wrong","hint":"try harder","note":"close enough"}`))
+	}))
+	sut := Client{}
+	req, err := http.NewRequest(http.MethodPost, fakeServer.URL, nil)
+	assert.NoError(t, err)
+	var respPayload TestPayload
+
+	// when
+	err = sut.Send(req, &respPayload)
+
+	// then
+	var apiErr *Error
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, Error{
+		Status:  http.StatusBadRequest,
+		Code:    -777,
+		Message: "Answer is wrong",
+		Hint:    "try harder",
+		Body:    map[string]any{"code": -777.0, "msg": "Answer is wrong", "hint": "try harder", "note": "close enough"},
+	}, *apiErr)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestShouldKeepPlainTextErrorBody(t *testing.T) {
+	// given
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("content-type", "text/plain")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("go away\n"))
+	}))
+	sut := Client{}
+	req, err := http.NewRequest(http.MethodGet, fakeServer.URL, nil)
+	assert.NoError(t, err)
+	var respPayload TestPayload
+
+	// when
+	err = sut.Send(req, &respPayload)
+
+	// then
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.ErrorContains(t, err, "status 403: go away")
+}
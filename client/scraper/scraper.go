@@ -7,12 +7,12 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/koenno/aidevs2/resilience"
 )
 
 var (
-	httpClient = http.Client{
-		Timeout: 2 * time.Minute,
-	}
+	httpClient = resilience.NewClient(2 * time.Minute)
 )
 
 type Client struct {
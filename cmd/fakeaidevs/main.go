@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/koenno/aidevs2/fakeaidevs"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "address to listen on")
+	fixturesDir := flag.String("fixtures", "data/fakeaidevs", "directory with '<task name>.json' fixtures")
+	apiKey := flag.String("aidevsKey", "", "accepted AIDevs API key, any key is accepted when empty")
+	flag.Parse()
+
+	fixtures, err := fakeaidevs.LoadFixtures(*fixturesDir)
+	if err != nil {
+		log.Fatalf("failed to load fixtures: %v", err)
+	}
+	log.Printf("loaded %d fixtures from %s", len(fixtures), *fixturesDir)
+
+	srv := fakeaidevs.NewServer(*apiKey, fixtures)
+	log.Printf("listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		log.Fatalf("server failure: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/fakeopenai"
+)
+
+func main() {
+	addr := flag.String("addr", ":8082", "address to listen on, clients use http://<addr>/v1 as the base URL")
+	scriptPath := flag.String("script", "", "script of canned AI responses")
+	flag.Parse()
+
+	script := ai.Script{}
+	if *scriptPath != "" {
+		var err error
+		if script, err = ai.LoadScript(*scriptPath); err != nil {
+			log.Fatalf("failed to load script: %v", err)
+		}
+	}
+
+	srv := fakeopenai.NewServer(ai.NewScripted(script))
+	log.Printf("listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		log.Fatalf("server failure: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+
+	"github.com/koenno/aidevs2/fakeqdrant"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", ":6334", "address the gRPC API listens on")
+	flag.Parse()
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("failed to listen on %s: %v", *addr, err)
+	}
+	srv := grpc.NewServer()
+	fakeqdrant.NewServer().Register(srv)
+	log.Printf("listening on %s", *addr)
+	if err := srv.Serve(lis); err != nil {
+		log.Fatalf("server failure: %v", err)
+	}
+}
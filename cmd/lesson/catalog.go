@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/lesson"
+)
+
+// list prints all lessons
+func list(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LESSON\tTASK\tSERVICES\tDESCRIPTION")
+	for _, info := range lesson.Lessons() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.ID, info.TaskName, joinServices(info.Services), info.Description)
+	}
+	return tw.Flush()
+}
+
+// describe prints details of the lesson given by id, alias or task name
+func describe(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("describe", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: lesson describe [flags] <lesson>\n")
+		fs.PrintDefaults()
+	}
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one lesson is expected")
+	}
+	info, err := lesson.Find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "lesson:\t%s\n", info.ID)
+	if len(info.Aliases) != 0 {
+		fmt.Fprintf(tw, "aliases:\t%s\n", strings.Join(info.Aliases, ", "))
+	}
+	fmt.Fprintf(tw, "task:\t%s\n", info.TaskName)
+	fmt.Fprintf(tw, "description:\t%s\n", info.Description)
+	fmt.Fprintf(tw, "services:\t%s\n", joinServices(info.Services))
+	fmt.Fprintf(tw, "models:\t%s\n", orNone(strings.Join(info.ModelNames(cfg), ", ")))
+	return tw.Flush()
+}
+
+func joinServices(services []lesson.Service) string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = string(s)
+	}
+	return orNone(strings.Join(names, ", "))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/lesson"
+	"github.com/koenno/aidevs2/recording"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cassettes are recorded from the module root with the real OpenAI and Qdrant clients talking to stand-ins:
+//
+//	fakeaidevs -addr :18081 -fixtures cmd/lesson/testdata/fakeaidevs
+//	fakeopenai -addr :18082 -script cmd/lesson/testdata/fakeopenai.json
+//	fakeqdrant -addr :18083
+//	lesson -lesson <id> -aidevsURL http://localhost:18081 -aidevsKey golden -openaiKey golden \
+//		-openaiURL http://localhost:18082/v1 -vectorDBAddr localhost:18083 -record cmd/lesson/testdata/cassettes/<id>.jsonl
+//
+// c03l04 is run once without -record first so the recording only searches the filled collection
+const (
+	goldenKey        = "golden"
+	goldenAIDevsURL  = "http://localhost:18081"
+	goldenOpenAIURL  = "http://localhost:18082/v1"
+	goldenQdrantAddr = "localhost:18083"
+)
+
+func TestShouldSolveLessonsFromCassettes(t *testing.T) {
+	paths, err := filepath.Glob("testdata/cassettes/*.jsonl")
+	require.NoError(t, err)
+	for i, path := range paths {
+		paths[i], err = filepath.Abs(path)
+		require.NoError(t, err)
+	}
+	// lessons read their data files relative to the module root
+	chdir(t, "../..")
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		t.Run(id, func(t *testing.T) {
+			// given
+			cassette, err := recording.Replay(path, recording.WithRedact(goldenKey))
+			require.NoError(t, err)
+			t.Cleanup(recording.Use(cassette))
+			cfg := config.Default()
+			cfg.AIDevs.URL = goldenAIDevsURL
+			cfg.OpenAI.URL = goldenOpenAIURL
+			cfg.VectorDB.Addr = goldenQdrantAddr
+			report := &Report{}
+			ts := TaskServer{
+				ApiKey:   goldenKey,
+				Endpoint: cfg.AIDevs.URL,
+				Report:   report,
+			}
+			container := lesson.NewContainer(ai.NewOpenAIWithURL(goldenKey, cfg.OpenAI.URL), cfg)
+			defer container.Close()
+
+			// when
+			err = lesson.CreateTaskSolver(id, container).Solve(context.Background(), ts)
+
+			// then
+			assert.NoError(t, err)
+			verdicts := report.Verdicts()
+			assert.NotEmpty(t, verdicts)
+			for _, v := range verdicts {
+				assert.True(t, v.Accepted, v.Feedback())
+			}
+			assert.Zero(t, cassette.Unused(), "not all recorded interactions were replayed")
+		})
+	}
+}
+
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+}
@@ -1,10 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/cache"
+	"github.com/koenno/aidevs2/config"
 	"github.com/koenno/aidevs2/lesson"
+	"github.com/koenno/aidevs2/recording"
 )
 
 type Task struct {
@@ -27,26 +38,141 @@ func (t *Task) SetToken(token string) {
 }
 
 func main() {
-	aidevsKey := flag.String("aidevsKey", "", "your AIDevs API key")
-	openaiKey := flag.String("openaiKey", "", "your OpenAI API key")
-	lessonName := flag.String("lesson", "", "lesson name")
-	flag.Parse()
-	if *aidevsKey == "" {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "list":
+			if err := list(os.Stdout); err != nil {
+				log.Fatalf("failed to list lessons: %v", err)
+			}
+			return
+		case "describe":
+			if err := describe(os.Stdout, os.Args[2:]); err != nil {
+				log.Fatalf("failed to describe lesson: %v", err)
+			}
+			return
+		case "run-all":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			ok, err := runAll(ctx, os.Stdout, os.Args[2:])
+			stop()
+			if err != nil {
+				log.Fatalf("failed to run lessons: %v", err)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	lessonName := flag.String("lesson", "", "lesson id, alias or task name, see the list command")
+	timeout := flag.Duration("timeout", 0, "time limit for solving the task, no limit when 0")
+	scriptPath := flag.String("script", "", "script of canned AI responses used instead of OpenAI")
+	recordPath := flag.String("record", "", "JSONL file all requests and responses are recorded to")
+	replayPath := flag.String("replay", "", "JSONL file of recorded requests answered instead of the network")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if *replayPath != "" {
+		// keys are redacted in the recording so any key matches, a random one cannot redact other text of the requests
+		placeholder := uuid.NewString()
+		cfg.AIDevs.Key = orDefault(cfg.AIDevs.Key, placeholder)
+		cfg.OpenAI.Key = orDefault(cfg.OpenAI.Key, placeholder)
+	}
+	if cfg.AIDevs.Key == "" {
 		log.Fatalf("AIDevs API key is required")
 	}
-	if *openaiKey == "" {
+	if cfg.OpenAI.Key == "" && *scriptPath == "" {
 		log.Fatalf("OpenAI API key is required")
 	}
+	cassette, err := openCassette(*recordPath, *replayPath, cfg.AIDevs.Key, cfg.OpenAI.Key)
+	if err != nil {
+		log.Fatalf("failed to open cassette: %v", err)
+	}
+	if cassette != nil {
+		defer recording.Use(cassette)()
+		// cached responses would be missing from the recording
+		cfg.Cache.Disabled = true
+	}
 	if *lessonName == "" {
 		log.Fatalf("lesson name is required")
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
 	ts := TaskServer{
-		ApiKey: *aidevsKey,
+		ApiKey:   cfg.AIDevs.Key,
+		Endpoint: cfg.AIDevs.URL,
+		Report:   &Report{},
+	}
+	provider, err := newProvider(cfg.OpenAI, *scriptPath)
+	if err != nil {
+		log.Fatalf("failed to create AI provider: %v", err)
+	}
+	ledger := ai.NewLedger()
+	provider = ai.NewMetered(provider, ledger)
+	var store *cache.Store
+	if !cfg.Cache.Disabled {
+		store, err = cache.New(cfg.Cache.Dir, cache.WithTTL(time.Duration(cfg.Cache.TTL)))
+		if err != nil {
+			log.Fatalf("failed to create cache: %v", err)
+		}
+		provider = ai.NewCached(provider, store)
+	}
+	container := lesson.NewContainer(provider, cfg)
+	solver := lesson.CreateTaskSolver(*lessonName, container)
+	err = solver.Solve(ctx, ts)
+	container.Close()
+	fmt.Printf("\nAnswers of lesson %s:\n", *lessonName)
+	ts.Report.Write(os.Stdout)
+	fmt.Printf("\nAI usage of lesson %s:\n", *lessonName)
+	ledger.WriteSummary(os.Stdout)
+	if store != nil {
+		stats := store.Stats()
+		fmt.Printf("cache: %d hits, %d misses\n", stats.Hits, stats.Misses)
+	}
+	if cassette != nil {
+		if err := cassette.Close(); err != nil {
+			log.Printf("%v", err)
+		}
 	}
-	solver := lesson.CreateTaskSolver(*lessonName, *openaiKey)
-	err := solver.Solve(ts)
 	if err != nil {
 		log.Fatalf("failed to solve task for lesson %s: %s", *lessonName, err)
 	}
 }
+
+func openCassette(recordPath, replayPath string, secrets ...string) (*recording.Cassette, error) {
+	switch {
+	case recordPath != "" && replayPath != "":
+		return nil, fmt.Errorf("record and replay cannot be used together")
+	case recordPath != "":
+		return recording.Record(recordPath, recording.WithRedact(secrets...))
+	case replayPath != "":
+		return recording.Replay(replayPath, recording.WithRedact(secrets...))
+	}
+	return nil, nil
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+func newProvider(cfg config.OpenAI, scriptPath string) (ai.Provider, error) {
+	if scriptPath == "" {
+		return ai.NewOpenAIWithURL(cfg.Key, cfg.URL), nil
+	}
+	script, err := ai.LoadScript(scriptPath)
+	if err != nil {
+		return nil, err
+	}
+	return ai.NewScripted(script), nil
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/cache"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/lesson"
+	"github.com/koenno/aidevs2/task"
+)
+
+// Status is the outcome of a single lesson in a batch run
+type Status string
+
+const (
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusError   Status = "error"
+	StatusSkipped Status = "skipped"
+)
+
+// Result describes the run of a single lesson
+type Result struct {
+	Lesson           string          `json:"lesson"`
+	Task             string          `json:"task"`
+	Status           Status          `json:"status"`
+	Error            string          `json:"error,omitempty"`
+	Duration         config.Duration `json:"duration"`
+	Calls            int             `json:"calls"`
+	PromptTokens     int             `json:"promptTokens"`
+	CompletionTokens int             `json:"completionTokens"`
+	Cost             float64         `json:"cost"`
+	Verdicts         []Verdict       `json:"verdicts,omitempty"`
+}
+
+type Verdict struct {
+	Accepted bool   `json:"accepted"`
+	Code     int    `json:"code"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// Summary is the report of a batch run
+type Summary struct {
+	Started  time.Time       `json:"started"`
+	Duration config.Duration `json:"duration"`
+	Passed   int             `json:"passed"`
+	Failed   int             `json:"failed"`
+	Errors   int             `json:"errors"`
+	Skipped  int             `json:"skipped"`
+	Cost     float64         `json:"cost"`
+	Results  []Result        `json:"results"`
+}
+
+// OK tells whether every lesson which was run has passed
+func (s Summary) OK() bool {
+	return s.Failed == 0 && s.Errors == 0
+}
+
+// solveFunc solves the lesson, answers are collected by the report and AI calls by the ledger
+type solveFunc func(ctx context.Context, info lesson.Info, report *Report, ledger *ai.Ledger) error
+
+// batch runs lessons concurrently, a failure or a panic of one lesson does not affect the others
+type batch struct {
+	parallel int
+	timeout  time.Duration
+	solve    solveFunc
+	// ownAPI serializes lessons serving the own API as all of them listen on the same address
+	ownAPI sync.Mutex
+}
+
+func (b *batch) run(ctx context.Context, lessons []lesson.Info) []Result {
+	results := make([]Result, len(lessons))
+	sem := make(chan struct{}, max(b.parallel, 1))
+	var wg sync.WaitGroup
+	for i, info := range lessons {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = Result{
+				Lesson: info.ID,
+				Task:   info.TaskName,
+				Status: StatusError,
+				Error:  ctx.Err().Error(),
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, info lesson.Info) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = b.runLesson(ctx, info)
+		}(i, info)
+	}
+	wg.Wait()
+	return results
+}
+
+func (b *batch) runLesson(ctx context.Context, info lesson.Info) Result {
+	if requires(info, lesson.ServiceOwnAPI) {
+		b.ownAPI.Lock()
+		defer b.ownAPI.Unlock()
+	}
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	log.Printf("lesson %s: started", info.ID)
+	report := &Report{}
+	ledger := ai.NewLedger()
+	start := time.Now()
+	err := b.safeSolve(ctx, info, report, ledger)
+	result := Result{
+		Lesson:   info.ID,
+		Task:     info.TaskName,
+		Status:   StatusPass,
+		Duration: config.Duration(time.Since(start).Round(time.Millisecond)),
+		Cost:     ledger.Cost(),
+	}
+	for _, u := range ledger.Summary() {
+		result.Calls += u.Calls
+		result.PromptTokens += u.PromptTokens
+		result.CompletionTokens += u.CompletionTokens
+	}
+	for _, v := range report.Verdicts() {
+		result.Verdicts = append(result.Verdicts, Verdict{
+			Accepted: v.Accepted,
+			Code:     v.Code,
+			Feedback: v.Feedback(),
+		})
+	}
+	switch {
+	case errors.Is(err, task.ErrRejected):
+		result.Status = StatusFail
+	case err != nil:
+		result.Status = StatusError
+	case len(result.Verdicts) == 0:
+		result.Status = StatusError
+		err = errors.New("no answer sent")
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	log.Printf("lesson %s: %s in %v", info.ID, result.Status, time.Duration(result.Duration))
+	return result
+}
+
+func (b *batch) safeSolve(ctx context.Context, info lesson.Info, report *Report, ledger *ai.Ledger) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("lesson %s: panic: %v\n%s", info.ID, r, debug.Stack())
+			err = fmt.Errorf("solver panicked: %v", r)
+		}
+	}()
+	return b.solve(ctx, info, report, ledger)
+}
+
+func requires(info lesson.Info, service lesson.Service) bool {
+	for _, s := range info.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+func summarize(started time.Time, results []Result) Summary {
+	s := Summary{
+		Started:  started,
+		Duration: config.Duration(time.Since(started).Round(time.Millisecond)),
+		Results:  results,
+	}
+	for _, r := range results {
+		switch r.Status {
+		case StatusPass:
+			s.Passed++
+		case StatusFail:
+			s.Failed++
+		case StatusError:
+			s.Errors++
+		case StatusSkipped:
+			s.Skipped++
+		}
+		s.Cost += r.Cost
+	}
+	return s
+}
+
+// WriteMarkdown writes the summary as a Markdown table followed by details of failed lessons
+func (s Summary) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Lessons run %s\n\n", s.Started.Format(time.RFC3339))
+	fmt.Fprintf(&b, "%d passed, %d failed, %d errors, %d skipped in %v, cost $%.4f\n\n",
+		s.Passed, s.Failed, s.Errors, s.Skipped, time.Duration(s.Duration), s.Cost)
+	fmt.Fprintln(&b, "| Lesson | Task | Status | Duration | Calls | Tokens | Cost |")
+	fmt.Fprintln(&b, "|---|---|---|---|---:|---:|---:|")
+	for _, r := range s.Results {
+		fmt.Fprintf(&b, "| %s | %s | %s | %v | %d | %d | $%.4f |\n",
+			r.Lesson, r.Task, strings.ToUpper(string(r.Status)), time.Duration(r.Duration),
+			r.Calls, r.PromptTokens+r.CompletionTokens, r.Cost)
+	}
+	for _, r := range s.Results {
+		if r.Status == StatusPass {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s (%s)\n\n", r.Lesson, r.Status)
+		if r.Error != "" {
+			fmt.Fprintf(&b, "```\n%s\n```\n", r.Error)
+		}
+		for i, v := range r.Verdicts {
+			if v.Feedback == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "\nanswer %d (code %d):\n```\n%s\n```\n", i+1, v.Code, v.Feedback)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s Summary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// selectLessons picks lessons given by names, all when none is given, and skips excluded ones or ones requiring unavailable services
+func selectLessons(names, excluded []string, unavailable []lesson.Service) (run []lesson.Info, skipped []Result, err error) {
+	selected := lesson.Lessons()
+	if len(names) != 0 {
+		selected, err = findLessons(names)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	exclude, err := findLessons(excluded)
+	if err != nil {
+		return nil, nil, err
+	}
+	isExcluded := make(map[string]bool)
+	for _, info := range exclude {
+		isExcluded[info.ID] = true
+	}
+	for _, info := range selected {
+		if isExcluded[info.ID] {
+			continue
+		}
+		reason := ""
+		for _, s := range unavailable {
+			if requires(info, s) {
+				reason = fmt.Sprintf("requires %s", s)
+				break
+			}
+		}
+		if reason != "" {
+			skipped = append(skipped, Result{
+				Lesson: info.ID,
+				Task:   info.TaskName,
+				Status: StatusSkipped,
+				Error:  reason,
+			})
+			continue
+		}
+		run = append(run, info)
+	}
+	return run, skipped, nil
+}
+
+func findLessons(names []string) ([]lesson.Info, error) {
+	var lessons []lesson.Info
+	seen := make(map[string]bool)
+	for _, name := range names {
+		info, err := lesson.Find(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[info.ID] {
+			seen[info.ID] = true
+			lessons = append(lessons, info)
+		}
+	}
+	return lessons, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runAll solves many lessons and writes the summary, it returns false when any lesson has not passed
+func runAll(ctx context.Context, w io.Writer, args []string) (bool, error) {
+	fs := flag.NewFlagSet("run-all", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: lesson run-all [flags]\n")
+		fs.PrintDefaults()
+	}
+	names := fs.String("lessons", "", "comma separated lessons to run, all when empty")
+	excluded := fs.String("exclude", "", "comma separated lessons not to run")
+	without := fs.String("without", "", "comma separated services which are not available, lessons requiring them are skipped")
+	parallel := fs.Int("parallel", 2, "number of lessons solved at the same time")
+	timeout := fs.Duration("timeout", 5*time.Minute, "time limit for solving a single lesson, no limit when 0")
+	scriptPath := fs.String("script", "", "script of canned AI responses used instead of OpenAI")
+	format := fs.String("format", "markdown", "format of the summary: markdown or json")
+	out := fs.String("out", "", "file the summary is written to, standard output when empty")
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		return false, err
+	}
+	if *format != "markdown" && *format != "json" {
+		return false, fmt.Errorf("unsupported format %s", *format)
+	}
+	if cfg.AIDevs.Key == "" {
+		return false, errors.New("AIDevs API key is required")
+	}
+	if cfg.OpenAI.Key == "" && *scriptPath == "" {
+		return false, errors.New("OpenAI API key is required")
+	}
+	var unavailable []lesson.Service
+	for _, s := range splitList(*without) {
+		unavailable = append(unavailable, lesson.Service(s))
+	}
+	lessons, skipped, err := selectLessons(splitList(*names), splitList(*excluded), unavailable)
+	if err != nil {
+		return false, err
+	}
+
+	provider, err := newProvider(cfg.OpenAI, *scriptPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to create AI provider: %v", err)
+	}
+	var store *cache.Store
+	if !cfg.Cache.Disabled {
+		store, err = cache.New(cfg.Cache.Dir, cache.WithTTL(time.Duration(cfg.Cache.TTL)))
+		if err != nil {
+			return false, fmt.Errorf("failed to create cache: %v", err)
+		}
+	}
+	b := &batch{
+		parallel: *parallel,
+		timeout:  *timeout,
+		solve: func(ctx context.Context, info lesson.Info, report *Report, ledger *ai.Ledger) error {
+			var p ai.Provider = ai.NewMetered(provider, ledger)
+			if store != nil {
+				p = ai.NewCached(p, store)
+			}
+			container := lesson.NewContainer(p, cfg)
+			defer container.Close()
+			ts := TaskServer{
+				ApiKey:   cfg.AIDevs.Key,
+				Endpoint: cfg.AIDevs.URL,
+				Report:   report,
+			}
+			return lesson.CreateTaskSolver(info.ID, container).Solve(ctx, ts)
+		},
+	}
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return false, fmt.Errorf("failed to create summary file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	started := time.Now()
+	results := append(b.run(ctx, lessons), skipped...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Lesson < results[j].Lesson
+	})
+	summary := summarize(started, results)
+	if *format == "json" {
+		err = summary.WriteJSON(w)
+	} else {
+		err = summary.WriteMarkdown(w)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to write summary: %v", err)
+	}
+	return summary.OK(), nil
+}
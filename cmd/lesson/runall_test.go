@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/lesson"
+	"github.com/koenno/aidevs2/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldIsolateFailuresOfLessons(t *testing.T) {
+	// given
+	lessons := []lesson.Info{{ID: "pass"}, {ID: "fail"}, {ID: "error"}, {ID: "panic"}, {ID: "silent"}}
+	sut := &batch{
+		parallel: 2,
+		solve: func(ctx context.Context, info lesson.Info, report *Report, ledger *ai.Ledger) error {
+			ledger.Record(ai.Usage{Model: "gpt-4", PromptTokens: 1000, CompletionTokens: 500})
+			switch info.ID {
+			case "pass":
+				report.add(task.Verdict{Accepted: true})
+			case "fail":
+				report.add(task.Verdict{Code: -3, Message: "wrong answer"})
+				return fmt.Errorf("failed to send solution: %w", task.ErrRejected)
+			case "error":
+				return errors.New("some error")
+			case "panic":
+				panic("boom")
+			}
+			return nil
+		},
+	}
+
+	// when
+	results := sut.run(context.Background(), lessons)
+
+	// then
+	require.Len(t, results, 5)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Equal(t, StatusFail, results[1].Status)
+	assert.Equal(t, "wrong answer", results[1].Verdicts[0].Feedback)
+	assert.Equal(t, StatusError, results[2].Status)
+	assert.Equal(t, "some error", results[2].Error)
+	assert.Equal(t, StatusError, results[3].Status)
+	assert.Contains(t, results[3].Error, "boom")
+	assert.Equal(t, StatusError, results[4].Status)
+	assert.Equal(t, "no answer sent", results[4].Error)
+	for _, r := range results {
+		assert.Equal(t, 1, r.Calls)
+		assert.Equal(t, 1000, r.PromptTokens)
+		assert.Greater(t, r.Cost, 0.0)
+	}
+}
+
+func TestShouldBoundConcurrencyAndSerializeOwnAPILessons(t *testing.T) {
+	// given
+	var running, maxRunning, ownAPIRunning, maxOwnAPIRunning atomic.Int32
+	lessons := []lesson.Info{
+		{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"},
+		{ID: "api1", Services: []lesson.Service{lesson.ServiceOwnAPI}},
+		{ID: "api2", Services: []lesson.Service{lesson.ServiceOwnAPI}},
+	}
+	track := func(counter, maxCounter *atomic.Int32) func() {
+		n := counter.Add(1)
+		for {
+			m := maxCounter.Load()
+			if n <= m || maxCounter.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		return func() { counter.Add(-1) }
+	}
+	sut := &batch{
+		parallel: 3,
+		solve: func(ctx context.Context, info lesson.Info, report *Report, ledger *ai.Ledger) error {
+			defer track(&running, &maxRunning)()
+			if len(info.Services) != 0 {
+				defer track(&ownAPIRunning, &maxOwnAPIRunning)()
+			}
+			time.Sleep(20 * time.Millisecond)
+			report.add(task.Verdict{Accepted: true})
+			return nil
+		},
+	}
+
+	// when
+	results := sut.run(context.Background(), lessons)
+
+	// then
+	assert.Len(t, results, 6)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+	assert.Equal(t, int32(1), maxOwnAPIRunning.Load())
+}
+
+func TestShouldStopLessonAfterTimeout(t *testing.T) {
+	// given
+	sut := &batch{
+		timeout: 10 * time.Millisecond,
+		solve: func(ctx context.Context, info lesson.Info, report *Report, ledger *ai.Ledger) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	// when
+	results := sut.run(context.Background(), []lesson.Info{{ID: "slow"}})
+
+	// then
+	assert.Equal(t, StatusError, results[0].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), results[0].Error)
+}
+
+func TestShouldSummarizeResults(t *testing.T) {
+	// given
+	results := []Result{
+		{Lesson: "c01l01", Task: "helloapi", Status: StatusPass, Cost: 0.5},
+		{Lesson: "c01l02", Task: "moderation", Status: StatusFail, Cost: 0.25, Verdicts: []Verdict{{Code: -3, Feedback: "wrong answer"}}},
+		{Lesson: "c03l01", Task: "rodo", Status: StatusSkipped, Error: "requires vectordb"},
+	}
+
+	// when
+	sut := summarize(time.Date(2023, 11, 20, 10, 0, 0, 0, time.UTC), results)
+	var md bytes.Buffer
+	err := sut.WriteMarkdown(&md)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, sut.OK())
+	assert.Equal(t, 1, sut.Passed)
+	assert.Equal(t, 1, sut.Failed)
+	assert.Equal(t, 1, sut.Skipped)
+	assert.Equal(t, 0.75, sut.Cost)
+	assert.Contains(t, md.String(), "1 passed, 1 failed, 0 errors, 1 skipped")
+	assert.Contains(t, md.String(), "| c01l02 | moderation | FAIL |")
+	assert.Contains(t, md.String(), "## c01l02 (fail)")
+	assert.Contains(t, md.String(), "wrong answer")
+	assert.NotContains(t, md.String(), "## c01l01")
+}
+
+func TestShouldSelectLessons(t *testing.T) {
+	// when
+	run, skipped, err := selectLessons([]string{"c3l1", "c3l4", "4a"}, []string{"4a"}, []lesson.Service{lesson.ServiceVectorDB})
+
+	// then
+	assert.NoError(t, err)
+	require.Len(t, run, 1)
+	assert.Equal(t, "c03l01", run[0].ID)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "c03l04", skipped[0].Lesson)
+	assert.Equal(t, "requires vectordb", skipped[0].Error)
+}
+
+func TestShouldRejectUnknownLessonSelection(t *testing.T) {
+	// when
+	_, _, err := selectLessons([]string{"c09l09"}, nil, nil)
+
+	// then
+	assert.ErrorIs(t, err, lesson.ErrUnknownLesson)
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"strings"
+	"sync"
 
 	"github.com/koenno/aidevs2/client/aidevs"
 	"github.com/koenno/aidevs2/request"
@@ -10,38 +15,107 @@ import (
 )
 
 var (
-	client     = aidevs.Client{}
-	reqFactory = request.Factory{}
+	client = aidevs.Client{}
 )
 
 type TaskServer struct {
-	ApiKey string
+	ApiKey   string
+	Endpoint string
+	Report   *Report
 }
 
-func (s TaskServer) FetchTask(name string, taskData task.AIDevsTask) error {
+// Report collects verdicts of all solutions sent during a run
+type Report struct {
+	mu       sync.Mutex
+	verdicts []task.Verdict
+}
+
+func (r *Report) add(v task.Verdict) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verdicts = append(r.verdicts, v)
+}
+
+// Verdicts returns verdicts in the order the answers were sent
+func (r *Report) Verdicts() []task.Verdict {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]task.Verdict(nil), r.verdicts...)
+}
+
+// Write prints pass or fail of every answer together with the server feedback
+func (r *Report) Write(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.verdicts) == 0 {
+		fmt.Fprintln(w, "no answer sent")
+		return
+	}
+	for i, v := range r.verdicts {
+		result := "PASS"
+		if !v.Accepted {
+			result = fmt.Sprintf("FAIL (code %d)", v.Code)
+		}
+		fmt.Fprintf(w, "answer %d: %s\n", i+1, result)
+		if feedback := v.Feedback(); feedback != "" {
+			fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(feedback, "\n", "\n  "))
+		}
+	}
+}
+
+func (s TaskServer) reqFactory() request.Factory {
+	return request.Factory{
+		Endpoint: s.Endpoint,
+	}
+}
+
+func (s TaskServer) FetchTask(ctx context.Context, name string, taskData task.AIDevsTask) error {
 	taskFetcher := task.Fetcher{
 		ApiKey:  s.ApiKey,
 		Client:  client,
-		Creator: reqFactory,
+		Creator: s.reqFactory(),
 	}
 
-	err := taskFetcher.Fetch(name, taskData)
+	err := taskFetcher.Fetch(ctx, name, taskData)
 	if err != nil {
-		return fmt.Errorf("failed to solve the task %s: %v", name, err)
+		return fmt.Errorf("failed to fetch the task %s: %w", name, err)
 	}
 	log.Printf("fetched following task: %#v", taskData)
 	return nil
 }
 
-func (s TaskServer) SendSolution(token string, solution any) error {
+type QuestionResponse struct {
+	task.Response
+	Answer string `json:"answer"`
+}
+
+func (s TaskServer) AskQuestion(ctx context.Context, token, question string) (string, error) {
+	req, err := s.reqFactory().Question(ctx, token, question)
+	if err != nil {
+		return "", fmt.Errorf("failed to create question request: %v", err)
+	}
+	var resp QuestionResponse
+	if err := client.Send(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to ask question: %w", err)
+	}
+	if resp.Code != 0 {
+		return "", fmt.Errorf("error response: %d - %s", resp.Code, resp.Msg)
+	}
+	return resp.Answer, nil
+}
+
+func (s TaskServer) SendSolution(ctx context.Context, token string, solution any) (task.Verdict, error) {
 	taskAnswerer := task.Answerer{
 		Client:  client,
-		Creator: reqFactory,
+		Creator: s.reqFactory(),
 	}
 	log.Printf("sending following solution: %#v", solution)
-	err := taskAnswerer.Answer(token, solution)
+	verdict, err := taskAnswerer.Answer(ctx, token, solution)
+	if s.Report != nil && (err == nil || errors.Is(err, task.ErrRejected)) {
+		s.Report.add(verdict)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to send an answers: %v", err)
+		return verdict, fmt.Errorf("failed to send an answers: %w", err)
 	}
-	return nil
+	return verdict, nil
 }
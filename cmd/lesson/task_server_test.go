@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/client/aidevs"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/lesson"
+	"github.com/koenno/aidevs2/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func newUnauthorizedServer(t *testing.T) TaskServer {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":-2,"msg":"invalid API key"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return TaskServer{
+		ApiKey:   "some-key",
+		Endpoint: srv.URL,
+	}
+}
+
+func TestShouldKeepSentinelErrorsOfTaskServer(t *testing.T) {
+	// given
+	sut := newUnauthorizedServer(t)
+
+	// when
+	fetchErr := sut.FetchTask(context.Background(), "helloapi", &task.Envelope[struct{}]{})
+	_, askErr := sut.AskQuestion(context.Background(), "some-token", "some question")
+
+	// then
+	assert.ErrorIs(t, fetchErr, aidevs.ErrUnauthorized)
+	assert.ErrorIs(t, askErr, aidevs.ErrUnauthorized)
+}
+
+func TestShouldKeepSentinelErrorsOfLessonRun(t *testing.T) {
+	// given
+	server := newUnauthorizedServer(t)
+	container := lesson.NewContainer(ai.NewScripted(ai.Script{}), config.Default())
+	defer container.Close()
+
+	// when
+	err := lesson.CreateTaskSolver("c01l01", container).Solve(context.Background(), server)
+
+	// then
+	assert.ErrorIs(t, err, aidevs.ErrUnauthorized)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/ownapi"
+)
+
+func main() {
+	pro := flag.Bool("pro", false, "remember facts told in earlier questions (ownapipro)")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.OpenAI.Key == "" {
+		log.Fatalf("OpenAI API key is required")
+	}
+
+	var opts []ownapi.Option
+	if *pro {
+		opts = append(opts, ownapi.WithMemory())
+	}
+	handler := ownapi.NewHandler(ai.NewChat(ai.NewOpenAIWithURL(cfg.OpenAI.Key, cfg.OpenAI.URL), ai.WithModel(cfg.Models.Chat)), opts...)
+	srv, err := ownapi.Listen(cfg.OwnAPI.Addr, handler)
+	if err != nil {
+		log.Fatalf("failed to start server: %v", err)
+	}
+	log.Printf("listening on %s", srv.URL())
+	if err := srv.Serve(); err != nil {
+		log.Fatalf("server failure: %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/koenno/aidevs2/cache"
+	"github.com/koenno/aidevs2/request"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// PathEnv points to the config file used when -config is not given
+	PathEnv = "AIDEVS2_CONFIG"
+
+	// vector database backends
+	VectorDBQdrant = "qdrant"
+	VectorDBMemory = "memory"
+
+	// DefaultVectorDBBatchSize matches vectordb.DefaultBatchSize, it is not imported to keep config free of database clients
+	DefaultVectorDBBatchSize = 100
+)
+
+// Config holds settings shared by commands, values are taken from defaults, the config file, environment and flags, the later overrides the former
+type Config struct {
+	AIDevs   AIDevs   `json:"aidevs"`
+	OpenAI   OpenAI   `json:"openai"`
+	Models   Models   `json:"models"`
+	VectorDB VectorDB `json:"vectorDB"`
+	NoSQLDB  Database `json:"noSQLDB"`
+	OwnAPI   OwnAPI   `json:"ownAPI"`
+	Cache    Cache    `json:"cache"`
+}
+
+type AIDevs struct {
+	Key string `json:"key"`
+	URL string `json:"url"`
+}
+
+type OpenAI struct {
+	Key string `json:"key"`
+	// URL is the base URL of the OpenAI API or a compatible one, e.g. the fakeopenai stand-in
+	URL string `json:"url"`
+}
+
+// Models names models used for different kinds of work
+type Models struct {
+	Chat      string `json:"chat"`
+	Reasoning string `json:"reasoning"`
+	Tools     string `json:"tools"`
+}
+
+type Database struct {
+	Addr string `json:"addr"`
+}
+
+type VectorDB struct {
+	// Backend is qdrant or memory
+	Backend string `json:"backend"`
+	// Addr of Qdrant
+	Addr string `json:"addr"`
+	// Path is the file the memory backend is persisted to, nothing is persisted when empty
+	Path string `json:"path"`
+	// BatchSize is the number of points sent to Qdrant by a single upsert
+	BatchSize int `json:"batchSize"`
+}
+
+type OwnAPI struct {
+	// Addr is the local address the own API server listens on
+	Addr string `json:"addr"`
+	// PublicURL is the URL the own API is reachable at, e.g. through a tunnel
+	PublicURL string `json:"publicURL"`
+}
+
+type Cache struct {
+	Disabled bool     `json:"disabled"`
+	Dir      string   `json:"dir"`
+	TTL      Duration `json:"ttl"`
+}
+
+// Duration is a time.Duration written as "1h30m" in the config file
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(bb []byte) error {
+	var s string
+	if err := json.Unmarshal(bb, &s); err != nil {
+		return fmt.Errorf("duration must be a string like 1h30m: %v", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: %v", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func Default() Config {
+	return Config{
+		AIDevs: AIDevs{
+			URL: request.DefaultEndpoint,
+		},
+		OpenAI: OpenAI{
+			URL: openai.DefaultConfig("").BaseURL,
+		},
+		Models: Models{
+			Chat:      openai.GPT3Dot5Turbo,
+			Reasoning: openai.GPT4,
+			Tools:     openai.GPT40613,
+		},
+		VectorDB: VectorDB{
+			Backend:   VectorDBQdrant,
+			Addr:      "localhost:6334",
+			BatchSize: DefaultVectorDBBatchSize,
+		},
+		NoSQLDB: Database{
+			Addr: "localhost:27017",
+		},
+		OwnAPI: OwnAPI{
+			Addr: ":8080",
+		},
+		Cache: Cache{
+			Dir: cache.DefaultDir(),
+			TTL: Duration(cache.DefaultTTL),
+		},
+	}
+}
+
+// setting binds a config field to its flag and environment variable
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field func(c *Config) any
+}
+
+var settings = []setting{
+	{"aidevsKey", "AIDEVS_KEY", "your AIDevs API key", func(c *Config) any { return &c.AIDevs.Key }},
+	{"aidevsURL", "AIDEVS_URL", "AIDevs API base URL", func(c *Config) any { return &c.AIDevs.URL }},
+	{"openaiKey", "OPENAI_API_KEY", "your OpenAI API key", func(c *Config) any { return &c.OpenAI.Key }},
+	{"openaiURL", "OPENAI_BASE_URL", "OpenAI API base URL", func(c *Config) any { return &c.OpenAI.URL }},
+	{"chatModel", "AIDEVS2_CHAT_MODEL", "model used for plain chats", func(c *Config) any { return &c.Models.Chat }},
+	{"reasoningModel", "AIDEVS2_REASONING_MODEL", "model used when a chat needs more reasoning", func(c *Config) any { return &c.Models.Reasoning }},
+	{"toolsModel", "AIDEVS2_TOOLS_MODEL", "model used for function calling", func(c *Config) any { return &c.Models.Tools }},
+	{"vectorDB", "AIDEVS2_VECTORDB", "vector database backend: qdrant or memory", func(c *Config) any { return &c.VectorDB.Backend }},
+	{"vectorDBAddr", "AIDEVS2_VECTORDB_ADDR", "address of the Qdrant vector database", func(c *Config) any { return &c.VectorDB.Addr }},
+	{"vectorDBPath", "AIDEVS2_VECTORDB_PATH", "file the memory vector database is persisted to, not persisted when empty", func(c *Config) any { return &c.VectorDB.Path }},
+	{"vectorDBBatchSize", "AIDEVS2_VECTORDB_BATCH_SIZE", "number of points sent to Qdrant by a single upsert", func(c *Config) any { return &c.VectorDB.BatchSize }},
+	{"noSQLDBAddr", "AIDEVS2_NOSQLDB_ADDR", "address of the MongoDB database", func(c *Config) any { return &c.NoSQLDB.Addr }},
+	{"ownAPIAddr", "OWNAPI_ADDR", "local address the own API server listens on", func(c *Config) any { return &c.OwnAPI.Addr }},
+	{"ownAPIURL", "OWNAPI_URL", "public URL of the own API, e.g. a tunnel to ownAPIAddr", func(c *Config) any { return &c.OwnAPI.PublicURL }},
+	{"no-cache", "AIDEVS2_NO_CACHE", "always call the AI provider instead of reusing cached responses", func(c *Config) any { return &c.Cache.Disabled }},
+	{"cacheDir", "AIDEVS2_CACHE_DIR", "directory of cached AI responses", func(c *Config) any { return &c.Cache.Dir }},
+	{"cacheTTL", "AIDEVS2_CACHE_TTL", "how long cached AI responses are valid, forever when 0", func(c *Config) any { return (*time.Duration)(&c.Cache.TTL) }},
+}
+
+// Load parses args with fs extended by config flags and builds the config, the file is given by -config or AIDEVS2_CONFIG
+func Load(fs *flag.FlagSet, args []string) (Config, error) {
+	flags := Default()
+	for _, s := range settings {
+		bindFlag(fs, s, s.field(&flags))
+	}
+	path := fs.String("config", os.Getenv(PathEnv), "JSON config file, see config.Config")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	if *path != "" {
+		if err := readFile(*path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := applyEnv(&cfg, os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name {
+				copyValue(s.field(&cfg), s.field(&flags))
+			}
+		}
+	})
+	return cfg, nil
+}
+
+func bindFlag(fs *flag.FlagSet, s setting, field any) {
+	switch v := field.(type) {
+	case *string:
+		fs.StringVar(v, s.flag, *v, s.usage)
+	case *bool:
+		fs.BoolVar(v, s.flag, *v, s.usage)
+	case *int:
+		fs.IntVar(v, s.flag, *v, s.usage)
+	case *time.Duration:
+		fs.DurationVar(v, s.flag, *v, s.usage)
+	default:
+		panic(fmt.Sprintf("unsupported type %T of setting %s", field, s.flag))
+	}
+}
+
+func readFile(path string, cfg *Config) error {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %v", path, err)
+	}
+	if err := json.Unmarshal(bb, cfg); err != nil {
+		return fmt.Errorf("failed to decode config %s: %v", path, err)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, s := range settings {
+		value, exist := lookup(s.env)
+		if !exist {
+			continue
+		}
+		if err := parseValue(s.field(cfg), value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %v", s.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseValue(field any, value string) error {
+	switch v := field.(type) {
+	case *string:
+		*v = value
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*v = b
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*v = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*v = d
+	}
+	return nil
+}
+
+func copyValue(dst, src any) {
+	switch v := dst.(type) {
+	case *string:
+		*v = *src.(*string)
+	case *bool:
+		*v = *src.(*bool)
+	case *int:
+		*v = *src.(*int)
+	case *time.Duration:
+		*v = *src.(*time.Duration)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv hides environment of the machine running tests, it is restored after the test
+func clearEnv(t *testing.T) {
+	for _, s := range append(settings, setting{env: PathEnv}) {
+		t.Setenv(s.env, "")
+		os.Unsetenv(s.env)
+	}
+}
+
+func TestShouldUseDefaultsWithoutOverrides(t *testing.T) {
+	// given
+	clearEnv(t)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	// when
+	cfg, err := Load(fs, nil)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestShouldOverrideFileWithEnvAndEnvWithFlags(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"aidevs": {"key": "file key", "url": "http://file"},
+		"vectorDB": {"backend": "memory", "addr": "file:6334"},
+		"cache": {"ttl": "1h"}
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	clearEnv(t)
+	t.Setenv("AIDEVS_KEY", "env key")
+	t.Setenv("AIDEVS2_VECTORDB_ADDR", "env:6334")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	// when
+	cfg, err := Load(fs, []string{"-config", path, "-vectorDBAddr", "flag:6334", "-no-cache"})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "env key", cfg.AIDevs.Key)
+	assert.Equal(t, "http://file", cfg.AIDevs.URL)
+	assert.Equal(t, "flag:6334", cfg.VectorDB.Addr)
+	assert.Equal(t, VectorDBMemory, cfg.VectorDB.Backend)
+	assert.Equal(t, Duration(time.Hour), cfg.Cache.TTL)
+	assert.True(t, cfg.Cache.Disabled)
+	assert.Equal(t, Default().NoSQLDB, cfg.NoSQLDB)
+}
+
+func TestShouldFailOnInvalidEnv(t *testing.T) {
+	// given
+	clearEnv(t)
+	t.Setenv("AIDEVS2_CACHE_TTL", "forever")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	// when
+	_, err := Load(fs, nil)
+
+	// then
+	assert.ErrorContains(t, err, "AIDEVS2_CACHE_TTL")
+}
+
+func TestShouldFailOnMissingConfigFile(t *testing.T) {
+	// given
+	clearEnv(t)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	// when
+	_, err := Load(fs, []string{"-config", filepath.Join(t.TempDir(), "missing.json")})
+
+	// then
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	DefaultBatchSize   = 100
+	DefaultConcurrency = 4
+)
+
+var (
+	ErrFlagged = errors.New("text does not fullfil usage policy")
+)
+
+// ProgressFunc is told how many of all texts are embedded so far
+type ProgressFunc func(done, total int)
+
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	size        int
+	concurrency int
+	progress    ProgressFunc
+}
+
+// WithBatchSize sets how many texts are embedded by a single request
+func WithBatchSize(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.size = n
+	}
+}
+
+// WithConcurrency sets how many requests are sent at the same time
+func WithConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithProgress reports progress after every embedded batch
+func WithProgress(progress ProgressFunc) BatchOption {
+	return func(o *batchOptions) {
+		o.progress = progress
+	}
+}
+
+// Embeddings embeds all texts with a single request, embeddings are in the order of texts
+func (e Embeddor) Embeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	inputs := make([]string, len(texts))
+	for i, text := range texts {
+		input, err := ai.TruncateText(string(openai.AdaEmbeddingV2), text, maxInputTokens)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fit text into embedding input: %v", err)
+		}
+		inputs[i] = input
+	}
+	req := openai.EmbeddingRequest{
+		Input: inputs,
+		Model: openai.AdaEmbeddingV2,
+	}
+	resp, err := e.Client.CreateEmbeddings(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("response failure for embeddings: %v", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("%d embeddings received for %d texts", len(resp.Data), len(texts))
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding of unknown text %d received", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	return embeddings, nil
+}
+
+// ModeratedEmbeddings embeds texts in batches sent concurrently, a batch is moderated as a whole and text by text only when it gets flagged
+func (e Embeddor) ModeratedEmbeddings(ctx context.Context, texts []string, opts ...BatchOption) ([][]float32, error) {
+	o := batchOptions{
+		size:        DefaultBatchSize,
+		concurrency: DefaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	size := max(o.size, 1)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	embeddings := make([][]float32, len(texts))
+	sem := make(chan struct{}, max(o.concurrency, 1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			batch, err := e.moderatedBatch(ctx, texts[start:end])
+			if err != nil {
+				cancel(fmt.Errorf("failed to embed texts %d-%d: %w", start, end-1, err))
+				return
+			}
+			copy(embeddings[start:end], batch)
+			mu.Lock()
+			defer mu.Unlock()
+			done += end - start
+			if o.progress != nil {
+				o.progress(done, len(texts))
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+func (e Embeddor) moderatedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	invalid, err := e.Moderator.Moderate(ctx, strings.Join(texts, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate embedding: %v", err)
+	}
+	if invalid {
+		for _, text := range texts {
+			invalid, err := e.Moderator.Moderate(ctx, text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to moderate embedding: %v", err)
+			}
+			if invalid {
+				return nil, fmt.Errorf("%w: %s", ErrFlagged, text)
+			}
+		}
+	}
+	return e.Embeddings(ctx, texts)
+}
@@ -0,0 +1,101 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/moderation"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient counts embedding requests and how many of them run at the same time
+type countingClient struct {
+	*ai.Scripted
+	requests   atomic.Int32
+	running    atomic.Int32
+	maxRunning atomic.Int32
+}
+
+func (c *countingClient) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	c.requests.Add(1)
+	n := c.running.Add(1)
+	defer c.running.Add(-1)
+	for m := c.maxRunning.Load(); n > m && !c.maxRunning.CompareAndSwap(m, n); m = c.maxRunning.Load() {
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c.Scripted.CreateEmbeddings(ctx, conv)
+}
+
+func newEmbeddor(script ai.Script) (Embeddor, *countingClient) {
+	client := &countingClient{Scripted: ai.NewScripted(script)}
+	return Embeddor{
+		Client:    client,
+		Moderator: moderation.Moderator{OpenAIMod: client},
+	}, client
+}
+
+func texts(n int) []string {
+	tt := make([]string, n)
+	for i := range tt {
+		tt[i] = fmt.Sprintf("text number %d", i)
+	}
+	return tt
+}
+
+func TestShouldEmbedTextsInConcurrentBatches(t *testing.T) {
+	// given
+	sut, client := newEmbeddor(ai.Script{})
+	var mu sync.Mutex
+	var progress []int
+
+	// when
+	embeddings, err := sut.ModeratedEmbeddings(context.Background(), texts(95), WithBatchSize(10), WithConcurrency(3), WithProgress(func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 95, total)
+		progress = append(progress, done)
+	}))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, embeddings, 95)
+	for i, e := range embeddings {
+		assert.Equal(t, ai.HashEmbedding(fmt.Sprintf("text number %d", i)), e)
+	}
+	assert.Equal(t, int32(10), client.requests.Load())
+	assert.LessOrEqual(t, client.maxRunning.Load(), int32(3))
+	assert.Len(t, progress, 10)
+	assert.Equal(t, 95, progress[len(progress)-1])
+}
+
+func TestShouldFailOnFlaggedText(t *testing.T) {
+	// given
+	sut, _ := newEmbeddor(ai.Script{Flagged: []string{"number 42"}})
+
+	// when
+	_, err := sut.ModeratedEmbeddings(context.Background(), texts(95), WithBatchSize(10))
+
+	// then
+	assert.ErrorIs(t, err, ErrFlagged)
+	assert.ErrorContains(t, err, "text number 42")
+}
+
+func TestShouldEmbedNothingWithoutTexts(t *testing.T) {
+	// given
+	sut, client := newEmbeddor(ai.Script{})
+
+	// when
+	embeddings, err := sut.ModeratedEmbeddings(context.Background(), nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, embeddings)
+	assert.Zero(t, client.requests.Load())
+}
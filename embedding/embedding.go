@@ -3,12 +3,16 @@ package embedding
 import (
 	"context"
 	"fmt"
-	"time"
 
-	"github.com/eapache/go-resiliency/retrier"
+	"github.com/koenno/aidevs2/ai"
 	"github.com/sashabaranov/go-openai"
 )
 
+const (
+	// ada v2 input limit
+	maxInputTokens = 8191
+)
+
 type AIClient interface {
 	CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
 }
@@ -23,11 +27,15 @@ type Embeddor struct {
 }
 
 func (e Embeddor) Embedding(ctx context.Context, text string) ([]float32, error) {
+	text, err := ai.TruncateText(string(openai.AdaEmbeddingV2), text, maxInputTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fit text into embedding input: %v", err)
+	}
 	req := openai.EmbeddingRequest{
 		Input: []string{text},
 		Model: openai.AdaEmbeddingV2,
 	}
-	resp, err := e.Client.CreateEmbeddings(context.Background(), req)
+	resp, err := e.Client.CreateEmbeddings(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("response failure for embeddings: %v", err)
 	}
@@ -43,14 +51,7 @@ func (e Embeddor) ModeratedEmbedding(ctx context.Context, text string) ([]float3
 		return nil, fmt.Errorf("failed to moderate embedding: %v", err)
 	}
 	if invalid {
-		return nil, fmt.Errorf("text does not fullfil usage policy")
+		return nil, ErrFlagged
 	}
-
-	var embedding []float32
-	r := retrier.New(retrier.ExponentialBackoff(10, 100*time.Millisecond), nil)
-	err = r.Run(func() error {
-		embedding, err = e.Embedding(ctx, text)
-		return err
-	})
-	return embedding, err
+	return e.Embedding(ctx, text)
 }
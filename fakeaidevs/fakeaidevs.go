@@ -0,0 +1,239 @@
+package fakeaidevs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	codeOK          = 0
+	codeWrongAnswer = -777
+	codeBadRequest  = -1
+)
+
+// Fixture describes how the fake server behaves for a single task
+type Fixture struct {
+	// Task is the payload returned for the task, code and msg are added when missing
+	Task map[string]any `json:"task"`
+	// Questions maps a question sent as a form to the answer
+	Questions map[string]string `json:"questions,omitempty"`
+	// Answer is the expected solution; any solution is accepted when empty
+	Answer json.RawMessage `json:"answer,omitempty"`
+}
+
+// Submission is a solution sent to the fake server
+type Submission struct {
+	Task     string          `json:"task"`
+	Token    string          `json:"token"`
+	Answer   json.RawMessage `json:"answer"`
+	Accepted bool            `json:"accepted"`
+}
+
+// LoadFixtures reads all '<task name>.json' files from the given directory
+func LoadFixtures(dir string) (map[string]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures in '%s': %v", dir, err)
+	}
+	fixtures := make(map[string]Fixture, len(paths))
+	for _, path := range paths {
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture '%s': %v", path, err)
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(bb, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to decode fixture '%s': %v", path, err)
+		}
+		taskName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		fixtures[taskName] = fixture
+	}
+	return fixtures, nil
+}
+
+type Server struct {
+	apiKey   string
+	fixtures map[string]Fixture
+	mux      *http.ServeMux
+
+	mu          sync.Mutex
+	tokens      map[string]string
+	submissions []Submission
+}
+
+// NewServer creates a stand-in for the AI Devs API; any API key is accepted when apiKey is empty
+func NewServer(apiKey string, fixtures map[string]Fixture) *Server {
+	s := &Server{
+		apiKey:   apiKey,
+		fixtures: fixtures,
+		tokens:   make(map[string]string),
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/token/", s.handleToken)
+	s.mux.HandleFunc("/task/", s.handleTask)
+	s.mux.HandleFunc("/answer/", s.handleAnswer)
+	s.mux.HandleFunc("/submissions", s.handleSubmissions)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("received request %s %s", r.Method, r.URL)
+	s.mux.ServeHTTP(w, r)
+}
+
+// Submissions returns all solutions sent so far
+func (s *Server) Submissions() []Submission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Submission(nil), s.submissions...)
+}
+
+type authenticateRequest struct {
+	ApiKey string `json:"apikey"`
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, codeBadRequest, "only POST method is supported", nil)
+		return
+	}
+	taskName := strings.TrimPrefix(r.URL.Path, "/token/")
+	if _, exist := s.fixtures[taskName]; !exist {
+		writeResponse(w, http.StatusNotFound, codeBadRequest, fmt.Sprintf("unknown task %s", taskName), nil)
+		return
+	}
+	var req authenticateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, codeBadRequest, fmt.Sprintf("invalid payload: %v", err), nil)
+		return
+	}
+	if req.ApiKey == "" || (s.apiKey != "" && req.ApiKey != s.apiKey) {
+		writeResponse(w, http.StatusForbidden, codeBadRequest, "invalid API key", nil)
+		return
+	}
+	token := uuid.NewString()
+	s.mu.Lock()
+	s.tokens[token] = taskName
+	s.mu.Unlock()
+	writeResponse(w, http.StatusOK, codeOK, "OK", map[string]any{"token": token})
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	taskName, fixture, ok := s.fixtureByToken(w, strings.TrimPrefix(r.URL.Path, "/task/"))
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeResponse(w, http.StatusOK, codeOK, fmt.Sprintf("task %s", taskName), fixture.Task)
+	case http.MethodPost:
+		question := r.FormValue("question")
+		answer, exist := fixture.Questions[question]
+		if !exist {
+			writeResponse(w, http.StatusBadRequest, codeBadRequest, fmt.Sprintf("unknown question '%s'", question), nil)
+			return
+		}
+		writeResponse(w, http.StatusOK, codeOK, "OK", map[string]any{"answer": answer})
+	default:
+		writeResponse(w, http.StatusMethodNotAllowed, codeBadRequest, "only GET and POST methods are supported", nil)
+	}
+}
+
+type answerRequest struct {
+	Answer json.RawMessage `json:"answer"`
+}
+
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, codeBadRequest, "only POST method is supported", nil)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, "/answer/")
+	taskName, fixture, ok := s.fixtureByToken(w, token)
+	if !ok {
+		return
+	}
+	var req answerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, codeBadRequest, fmt.Sprintf("invalid payload: %v", err), nil)
+		return
+	}
+	accepted, err := matches(fixture.Answer, req.Answer)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, codeBadRequest, fmt.Sprintf("invalid answer: %v", err), nil)
+		return
+	}
+	s.mu.Lock()
+	s.submissions = append(s.submissions, Submission{
+		Task:     taskName,
+		Token:    token,
+		Answer:   req.Answer,
+		Accepted: accepted,
+	})
+	s.mu.Unlock()
+	log.Printf("answer for task %s: %s (accepted: %t)", taskName, req.Answer, accepted)
+	if !accepted {
+		writeResponse(w, http.StatusBadRequest, codeWrongAnswer, "Answer is wrong", nil)
+		return
+	}
+	writeResponse(w, http.StatusOK, codeOK, "OK", map[string]any{"note": "CORRECT"})
+}
+
+func (s *Server) handleSubmissions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Submissions()); err != nil {
+		log.Printf("failed to encode submissions: %v", err)
+	}
+}
+
+func (s *Server) fixtureByToken(w http.ResponseWriter, token string) (string, Fixture, bool) {
+	s.mu.Lock()
+	taskName, exist := s.tokens[token]
+	s.mu.Unlock()
+	if !exist {
+		writeResponse(w, http.StatusNotFound, codeBadRequest, "unknown token", nil)
+		return "", Fixture{}, false
+	}
+	return taskName, s.fixtures[taskName], true
+}
+
+func matches(expected, actual json.RawMessage) (bool, error) {
+	if len(expected) == 0 {
+		return true, nil
+	}
+	var expectedVal, actualVal any
+	if err := json.Unmarshal(expected, &expectedVal); err != nil {
+		return false, fmt.Errorf("failed to decode expected answer: %v", err)
+	}
+	if err := json.Unmarshal(actual, &actualVal); err != nil {
+		return false, fmt.Errorf("failed to decode answer: %v", err)
+	}
+	return reflect.DeepEqual(expectedVal, actualVal), nil
+}
+
+func writeResponse(w http.ResponseWriter, status, code int, msg string, payload map[string]any) {
+	resp := map[string]any{}
+	for k, v := range payload {
+		resp[k] = v
+	}
+	if _, exist := resp["code"]; !exist {
+		resp["code"] = code
+	}
+	if _, exist := resp["msg"]; !exist {
+		resp["msg"] = msg
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package fakeaidevs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koenno/aidevs2/client/aidevs"
+	"github.com/koenno/aidevs2/request"
+	"github.com/koenno/aidevs2/task"
+	"github.com/stretchr/testify/assert"
+)
+
+type helloTask struct {
+	task.Response
+	Token  string
+	Cookie string `json:"cookie"`
+}
+
+func (t *helloTask) GetCode() int {
+	return t.Code
+}
+
+func (t *helloTask) GetMsg() string {
+	return t.Msg
+}
+
+func (t *helloTask) SetToken(token string) {
+	t.Token = token
+}
+
+func newTestServer(t *testing.T) (*Server, request.Factory) {
+	fixtures := map[string]Fixture{
+		"helloapi": {
+			Task:      map[string]any{"cookie": "some cookie"},
+			Questions: map[string]string{"some question": "some answer"},
+			Answer:    json.RawMessage(`"some cookie"`),
+		},
+	}
+	sut := NewServer("", fixtures)
+	httpServer := httptest.NewServer(sut)
+	t.Cleanup(httpServer.Close)
+	return sut, request.Factory{Endpoint: httpServer.URL}
+}
+
+func TestShouldServeTaskFromFixture(t *testing.T) {
+	// given
+	_, factory := newTestServer(t)
+	fetcher := task.Fetcher{
+		ApiKey:  "some key",
+		Client:  aidevs.Client{},
+		Creator: factory,
+	}
+	var payload helloTask
+
+	// when
+	err := fetcher.Fetch(context.Background(), "helloapi", &payload)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some cookie", payload.Cookie)
+	assert.NotEmpty(t, payload.Token)
+}
+
+func TestShouldAnswerQuestionFromFixture(t *testing.T) {
+	// given
+	_, factory := newTestServer(t)
+	fetcher := task.Fetcher{
+		ApiKey:  "some key",
+		Client:  aidevs.Client{},
+		Creator: factory,
+	}
+	var payload helloTask
+	assert.NoError(t, fetcher.Fetch(context.Background(), "helloapi", &payload))
+	req, err := factory.Question(context.Background(), payload.Token, "some question")
+	assert.NoError(t, err)
+	var resp struct {
+		task.Response
+		Answer string `json:"answer"`
+	}
+
+	// when
+	err = aidevs.Client{}.Send(req, &resp)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "some answer", resp.Answer)
+}
+
+func TestShouldRecordSubmittedAnswers(t *testing.T) {
+	// given
+	sut, factory := newTestServer(t)
+	fetcher := task.Fetcher{
+		ApiKey:  "some key",
+		Client:  aidevs.Client{},
+		Creator: factory,
+	}
+	answerer := task.Answerer{
+		Client:  aidevs.Client{},
+		Creator: factory,
+	}
+	var payload helloTask
+	assert.NoError(t, fetcher.Fetch(context.Background(), "helloapi", &payload))
+
+	// when
+	wrong, errWrong := answerer.Answer(context.Background(), payload.Token, "wrong cookie")
+	correct, errCorrect := answerer.Answer(context.Background(), payload.Token, "some cookie")
+
+	// then
+	assert.ErrorIs(t, errWrong, task.ErrRejected)
+	assert.Equal(t, task.Verdict{Code: -777, Message: "Answer is wrong"}, wrong)
+	assert.NoError(t, errCorrect)
+	assert.Equal(t, task.Verdict{Accepted: true, Message: "OK", Extra: map[string]any{"note": "CORRECT"}}, correct)
+	submissions := sut.Submissions()
+	assert.Len(t, submissions, 2)
+	assert.False(t, submissions[0].Accepted)
+	assert.True(t, submissions[1].Accepted)
+	assert.JSONEq(t, `"some cookie"`, string(submissions[1].Answer))
+}
+
+func TestShouldLoadFixturesFromRepository(t *testing.T) {
+	// when
+	fixtures, err := LoadFixtures("../data/fakeaidevs")
+
+	// then
+	assert.NoError(t, err)
+	assert.Contains(t, fixtures, "helloapi")
+}
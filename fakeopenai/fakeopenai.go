@@ -0,0 +1,152 @@
+package fakeopenai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Server is a stand-in for the OpenAI HTTP API answering chats, embeddings and moderations with a provider,
+// together with ai.Scripted it lets the real OpenAI client be recorded without reaching OpenAI
+type Server struct {
+	provider ai.Provider
+	mux      *http.ServeMux
+}
+
+func NewServer(provider ai.Provider) *Server {
+	s := &Server{
+		provider: provider,
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChatCompletion)
+	s.mux.HandleFunc("/v1/embeddings", s.handleEmbeddings)
+	s.mux.HandleFunc("/v1/moderations", s.handleModerations)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("received request %s %s", r.Method, r.URL)
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "only POST method is supported")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleChatCompletion(w http.ResponseWriter, r *http.Request) {
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid payload: %v", err))
+		return
+	}
+	if req.Stream {
+		s.streamChatCompletion(w, r, req)
+		return
+	}
+	resp, err := s.provider.CreateChatCompletion(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) streamChatCompletion(w http.ResponseWriter, r *http.Request, req openai.ChatCompletionRequest) {
+	stream, err := s.provider.CreateChatCompletionStream(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer stream.Close()
+	w.Header().Set("content-type", "text/event-stream")
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("failed to receive chunk: %v", err)
+			return
+		}
+		bb, err := json.Marshal(chunk)
+		if err != nil {
+			log.Printf("failed to encode chunk: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", bb)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+type embeddingRequest struct {
+	Input json.RawMessage       `json:"input"`
+	Model openai.EmbeddingModel `json:"model"`
+}
+
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req embeddingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid payload: %v", err))
+		return
+	}
+	var inputs []string
+	if err := json.Unmarshal(req.Input, &inputs); err != nil {
+		var input string
+		if err := json.Unmarshal(req.Input, &input); err != nil {
+			writeError(w, http.StatusBadRequest, "input must be a string or a list of strings")
+			return
+		}
+		inputs = []string{input}
+	}
+	resp, err := s.provider.CreateEmbeddings(r.Context(), openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: req.Model,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleModerations(w http.ResponseWriter, r *http.Request) {
+	var req openai.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid payload: %v", err))
+		return
+	}
+	resp, err := s.provider.Moderations(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
+
+// writeError responds in the format of OpenAI errors so the client reports the message
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	resp := map[string]any{
+		"error": map[string]any{
+			"message": msg,
+			"type":    "invalid_request_error",
+		},
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
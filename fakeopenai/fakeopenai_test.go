@@ -0,0 +1,102 @@
+package fakeopenai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, script ai.Script) *ai.OpenAI {
+	srv := httptest.NewServer(NewServer(ai.NewScripted(script)))
+	t.Cleanup(srv.Close)
+	cfg := openai.DefaultConfig("some-key")
+	cfg.BaseURL = srv.URL + "/v1"
+	return ai.NewOpenAIWithConfig(cfg)
+}
+
+func TestShouldCompleteChatWithRealClient(t *testing.T) {
+	// given
+	sut := newTestClient(t, ai.Script{Chat: []ai.ScriptedReply{{Match: "capital of Poland", Content: "Warsaw"}}})
+
+	// when
+	resp, err := sut.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "What is the capital of Poland?"}},
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, resp.Choices, 1)
+	assert.Equal(t, "Warsaw", resp.Choices[0].Message.Content)
+}
+
+func TestShouldStreamChatWithRealClient(t *testing.T) {
+	// given
+	sut := newTestClient(t, ai.Script{Chat: []ai.ScriptedReply{{Content: "Pizza Margherita to klasyka."}}})
+
+	// when
+	stream, err := sut.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Napisz o pizzy"}},
+		Stream:   true,
+	})
+
+	// then
+	require.NoError(t, err)
+	defer stream.Close()
+	var sb strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		sb.WriteString(chunk.Choices[0].Delta.Content)
+	}
+	assert.Equal(t, "Pizza Margherita to klasyka.", sb.String())
+}
+
+func TestShouldEmbedAndModerateWithRealClient(t *testing.T) {
+	// given
+	sut := newTestClient(t, ai.Script{Flagged: []string{"głupi"}})
+
+	// when
+	embeddings, embedErr := sut.CreateEmbeddings(context.Background(), openai.EmbeddingRequestStrings{
+		Input: []string{"Hawaiian pizza", "pizza"},
+		Model: openai.AdaEmbeddingV2,
+	})
+	moderation, moderateErr := sut.Moderations(context.Background(), openai.ModerationRequest{Input: "jesteś głupi"})
+
+	// then
+	require.NoError(t, embedErr)
+	require.Len(t, embeddings.Data, 2)
+	assert.Equal(t, ai.HashEmbedding("pizza"), embeddings.Data[1].Embedding)
+	require.NoError(t, moderateErr)
+	require.Len(t, moderation.Results, 1)
+	assert.True(t, moderation.Results[0].Flagged)
+}
+
+func TestShouldReportMissingReplyAsOpenAIError(t *testing.T) {
+	// given
+	sut := newTestClient(t, ai.Script{})
+
+	// when
+	_, err := sut.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "unexpected"}},
+	})
+
+	// then
+	var apiErr *openai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 400, apiErr.HTTPStatusCode)
+	assert.Contains(t, apiErr.Message, "no scripted reply")
+}
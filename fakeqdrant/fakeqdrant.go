@@ -0,0 +1,159 @@
+package fakeqdrant
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	qdrant "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Server is an in-memory stand-in for the Qdrant gRPC API, it covers collections listing and creation,
+// upserts and cosine similarity search without filters
+type Server struct {
+	mu          sync.Mutex
+	collections map[string]*collection
+}
+
+type collection struct {
+	points map[string]*qdrant.PointStruct
+}
+
+func NewServer() *Server {
+	return &Server{
+		collections: make(map[string]*collection),
+	}
+}
+
+// Register serves the collections and points services of the fake with the gRPC server
+func (s *Server) Register(srv *grpc.Server) {
+	qdrant.RegisterCollectionsServer(srv, collectionsServer{Server: s})
+	qdrant.RegisterPointsServer(srv, pointsServer{Server: s})
+}
+
+// services are served by separate types as both have methods of the same names, e.g. Get
+type collectionsServer struct {
+	qdrant.UnimplementedCollectionsServer
+	*Server
+}
+
+type pointsServer struct {
+	qdrant.UnimplementedPointsServer
+	*Server
+}
+
+func (s collectionsServer) List(ctx context.Context, req *qdrant.ListCollectionsRequest) (*qdrant.ListCollectionsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.collections))
+	for name := range s.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	resp := &qdrant.ListCollectionsResponse{}
+	for _, name := range names {
+		resp.Collections = append(resp.Collections, &qdrant.CollectionDescription{Name: name})
+	}
+	return resp, nil
+}
+
+func (s collectionsServer) Create(ctx context.Context, req *qdrant.CreateCollection) (*qdrant.CollectionOperationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exist := s.collections[req.GetCollectionName()]; exist {
+		return nil, status.Errorf(codes.AlreadyExists, "collection %s already exists", req.GetCollectionName())
+	}
+	s.collections[req.GetCollectionName()] = &collection{
+		points: make(map[string]*qdrant.PointStruct),
+	}
+	log.Printf("collection %s created", req.GetCollectionName())
+	return &qdrant.CollectionOperationResponse{Result: true}, nil
+}
+
+func (s pointsServer) Upsert(ctx context.Context, req *qdrant.UpsertPoints) (*qdrant.PointsOperationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, exist := s.collections[req.GetCollectionName()]
+	if !exist {
+		return nil, status.Errorf(codes.NotFound, "collection %s not found", req.GetCollectionName())
+	}
+	for _, p := range req.GetPoints() {
+		c.points[pointID(p.GetId())] = proto.Clone(p).(*qdrant.PointStruct)
+	}
+	return &qdrant.PointsOperationResponse{
+		Result: &qdrant.UpdateResult{Status: qdrant.UpdateStatus_Completed},
+	}, nil
+}
+
+func (s pointsServer) Search(ctx context.Context, req *qdrant.SearchPoints) (*qdrant.SearchResponse, error) {
+	if req.GetFilter() != nil {
+		return nil, status.Error(codes.Unimplemented, "filters are not supported by the fake")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, exist := s.collections[req.GetCollectionName()]
+	if !exist {
+		return nil, status.Errorf(codes.NotFound, "collection %s not found", req.GetCollectionName())
+	}
+	var found []*qdrant.ScoredPoint
+	for _, p := range c.points {
+		score := cosine(req.GetVector(), p.GetVectors().GetVector().GetData())
+		if req.ScoreThreshold != nil && score < req.GetScoreThreshold() {
+			continue
+		}
+		sp := &qdrant.ScoredPoint{
+			Id:    p.GetId(),
+			Score: score,
+		}
+		if req.GetWithPayload().GetEnable() {
+			sp.Payload = p.GetPayload()
+		}
+		if req.GetWithVectors().GetEnable() {
+			sp.Vectors = p.GetVectors()
+		}
+		found = append(found, sp)
+	}
+	// ties are ordered by id so results do not depend on map order
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Score != found[j].Score {
+			return found[i].Score > found[j].Score
+		}
+		return pointID(found[i].Id) < pointID(found[j].Id)
+	})
+	offset := min(req.GetOffset(), uint64(len(found)))
+	found = found[offset:]
+	if limit := req.GetLimit(); limit < uint64(len(found)) {
+		found = found[:limit]
+	}
+	return &qdrant.SearchResponse{Result: found}, nil
+}
+
+func pointID(id *qdrant.PointId) string {
+	if uuid := id.GetUuid(); uuid != "" {
+		return uuid
+	}
+	return prototext.Format(id)
+}
+
+func cosine(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
@@ -0,0 +1,79 @@
+package fakeqdrant
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/koenno/aidevs2/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const collectionName = "some-collection"
+
+type item struct {
+	ID     string    `qdrant:"_id"`
+	Vector []float32 `qdrant:"_vector"`
+	Name   string    `qdrant:"name"`
+	Score  float32   `qdrant:"_score"`
+}
+
+func vector(x, y float32) []float32 {
+	v := make([]float32, vectordb.VectorSize)
+	v[0], v[1] = x, y
+	return v
+}
+
+func newTestDB(t *testing.T) *vectordb.DB {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	NewServer().Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	db, err := vectordb.New(lis.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestShouldStoreAndSearchPointsWithRealClient(t *testing.T) {
+	// given
+	ctx := context.Background()
+	db := newTestDB(t)
+	require.NoError(t, db.CreateCollection(ctx, collectionName))
+	require.NoError(t, db.UpsertMany(ctx, collectionName, []any{
+		item{ID: "00000000-0000-0000-0000-000000000001", Vector: vector(1, 0), Name: "east"},
+		item{ID: "00000000-0000-0000-0000-000000000002", Vector: vector(1, 1), Name: "north-east"},
+		item{ID: "00000000-0000-0000-0000-000000000003", Vector: vector(0, 1), Name: "north"},
+	}))
+
+	// when
+	exist, existErr := db.CollectionExist(ctx, collectionName)
+	var found []item
+	searchErr := db.Search(ctx, collectionName, vector(1, 0), &found, vectordb.WithLimit(2), vectordb.WithScoreThreshold(0.5))
+
+	// then
+	assert.NoError(t, existErr)
+	assert.True(t, exist)
+	assert.NoError(t, searchErr)
+	require.Len(t, found, 2)
+	assert.Equal(t, "east", found[0].Name)
+	assert.InDelta(t, 1, found[0].Score, 1e-6)
+	assert.Equal(t, "north-east", found[1].Name)
+	assert.Equal(t, vector(1, 1), found[1].Vector)
+}
+
+func TestShouldRejectSearchOfUnknownCollection(t *testing.T) {
+	// given
+	db := newTestDB(t)
+	var found []item
+
+	// when
+	err := db.Search(context.Background(), "unknown", vector(1, 0), &found, vectordb.WithLimit(1))
+
+	// then
+	assert.ErrorContains(t, err, "not found")
+}
@@ -1,12 +1,20 @@
 package country
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
+
+	"github.com/koenno/aidevs2/resilience"
+)
+
+var (
+	httpClient = resilience.NewClient(30 * time.Second)
 )
 
 type Knowledge struct {
@@ -54,7 +62,7 @@ type CountryInfo struct {
 	Population int `json:"population"`
 }
 
-func (k *Knowledge) Info(name string, opts ...Option) (CountryInfo, error) {
+func (k *Knowledge) Info(ctx context.Context, name string, opts ...Option) (CountryInfo, error) {
 	cfg := &options{}
 	for _, o := range opts {
 		o(cfg)
@@ -64,11 +72,11 @@ func (k *Knowledge) Info(name string, opts ...Option) (CountryInfo, error) {
 	if err != nil {
 		return CountryInfo{}, fmt.Errorf("failed to create URL: %v", err)
 	}
-	req, err := http.NewRequest(http.MethodGet, URL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), nil)
 	if err != nil {
 		return CountryInfo{}, fmt.Errorf("failed to create request to %s: %v", URL, err)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return CountryInfo{}, fmt.Errorf("failed to send request to %s: %v", URL, err)
 	}
@@ -1,11 +1,19 @@
 package currency
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"time"
+
+	"github.com/koenno/aidevs2/resilience"
+)
+
+var (
+	httpClient = resilience.NewClient(30 * time.Second)
 )
 
 type Knowledge struct {
@@ -26,18 +34,18 @@ type CurrencyInfo struct {
 	} `json:"rates"`
 }
 
-func (k *Knowledge) TodaysCurrency(code string) (float64, error) {
+func (k *Knowledge) TodaysCurrency(ctx context.Context, code string) (float64, error) {
 	rawURL := fmt.Sprintf("http://api.nbp.pl/api/exchangerates/rates/a/%s/today/", code)
 	URL, err := url.Parse(rawURL)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create URL: %v", err)
 	}
-	req, err := http.NewRequest(http.MethodGet, URL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request to %s: %v", URL, err)
 	}
 	req.Header.Add("Host", "")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to send request to %s: %v", URL, err)
 	}
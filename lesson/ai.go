@@ -0,0 +1,56 @@
+package lesson
+
+import (
+	"context"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/embedding"
+	"github.com/sashabaranov/go-openai"
+)
+
+// interfaces of AI capabilities used by solvers, all of them are fulfilled by ai.Provider or types built on it
+
+type AIChat interface {
+	ModeratedChat(ctx context.Context, system string, userMsgs ...string) (string, error)
+}
+
+type AIConversationalist interface {
+	ModeratedComplete(ctx context.Context, conv *ai.Conversation) (string, error)
+}
+
+type AIStructurer interface {
+	ModeratedCompleteInto(ctx context.Context, conv *ai.Conversation, target any, opts ...ai.StructuredOption) error
+}
+
+type AIToolRunner interface {
+	ModeratedRunTools(ctx context.Context, conv *ai.Conversation, tools *ai.Tools, opts ...ai.RunOption) (ai.ToolTrace, error)
+}
+
+type AIStreamer interface {
+	ModeratedStream(ctx context.Context, conv *ai.Conversation, onDelta ai.StreamFunc, opts ...ai.RequestOption) (ai.StreamResult, error)
+}
+
+type AIVisioner interface {
+	ModeratedSee(ctx context.Context, system, user, assistant, imageURI string) (string, error)
+}
+
+type Embeddor interface {
+	CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+}
+
+type ModeratedEmbeddor interface {
+	ModeratedEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+type BatchEmbeddor interface {
+	ModeratedEmbeddor
+	ModeratedEmbeddings(ctx context.Context, texts []string, opts ...embedding.BatchOption) ([][]float32, error)
+}
+
+type Transcriptor interface {
+	CreateTranscription(context.Context, openai.AudioRequest) (openai.AudioResponse, error)
+}
+
+type Moderator interface {
+	Moderate(ctx context.Context, entry string) (bool, error)
+}
@@ -6,72 +6,30 @@ import (
 	"log"
 	"strings"
 
-	"github.com/koenno/aidevs2/moderation"
-	"github.com/sashabaranov/go-openai"
+	"github.com/koenno/aidevs2/ai"
 )
 
 func init() {
-	registry["c02l02"] = C02L02Creator{}
-}
-
-type C02L02Creator struct {
-}
-
-func (c C02L02Creator) Create(openaiKey string) TaskSolver {
-	client := openai.NewClient(openaiKey)
-	return C02L02{
-		completor: client,
-		moderator: moderation.Moderator{
-			OpenAIMod: client,
-		},
-		taskName: "inprompt",
-	}
+	Define("c02l02", "inprompt", func(ctx context.Context, task C02L02Task, deps Deps) (C02L02Solution, error) {
+		l := C02L02{
+			chat: deps.Chat(),
+		}
+		return l.getSolution(ctx, task)
+	}, Describe("Answer a question about a person using only the matching facts"), Requires(ServiceOpenAI), Models(ModelChat))
 }
 
 type C02L02 struct {
-	completor ChatCompletor
-	moderator Moderator
-	taskName  string
+	chat AIConversationalist
 }
 
 type C02L02Task struct {
-	Task
 	Input    []string `json:"input"`
 	Question string   `json:"question"`
 }
 
-func (t *C02L02Task) GetCode() int {
-	return t.Code
-}
-
-func (t *C02L02Task) GetMsg() string {
-	return t.Msg
-}
-
-func (t *C02L02Task) SetToken(token string) {
-	t.Token = token
-}
-
 type C02L02Solution string
 
-func (l C02L02) Solve(server TaskServer) error {
-	var task C02L02Task
-	err := server.FetchTask(l.taskName, &task)
-	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
-	}
-	solution, err := l.getSolution(task)
-	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
-	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
-	}
-	return nil
-}
-
-func (l C02L02) getSolution(task C02L02Task) (C02L02Solution, error) {
+func (l C02L02) getSolution(ctx context.Context, task C02L02Task) (C02L02Solution, error) {
 	const rules = `
 	Strict rules of this conversation:
 	- I'm strictly forbidden to use any knowledge outside the context below and I always refuse to answer such question mentioning this rule.
@@ -85,7 +43,7 @@ func (l C02L02) getSolution(task C02L02Task) (C02L02Solution, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to relate facts to names: %v", err)
 	}
-	askedName, err := l.getNameByAI(task.Question)
+	askedName, err := l.getNameByAI(ctx, task.Question)
 	if err != nil {
 		return "", fmt.Errorf("failed to retrieve name from the question: %v", err)
 	}
@@ -95,7 +53,7 @@ func (l C02L02) getSolution(task C02L02Task) (C02L02Solution, error) {
 	}
 	promptContext := l.getContext(askedFacts)
 	system := rules + promptContext
-	resp, err := l.moderatedChat(system, prompt, "")
+	resp, err := l.moderatedChat(ctx, system, prompt)
 	if err != nil {
 		return "", fmt.Errorf("solution chat failure: %v", err)
 	}
@@ -108,9 +66,9 @@ func (l C02L02) getName(text string) (string, error) {
 	return tokens[0], nil
 }
 
-func (l C02L02) getNameByAI(text string) (string, error) {
+func (l C02L02) getNameByAI(ctx context.Context, text string) (string, error) {
 	user := "give only the name"
-	resp, err := l.moderatedChat(text, user, "")
+	resp, err := l.moderatedChat(ctx, text, user)
 	if err != nil {
 		return "", fmt.Errorf("name retrieval chat failure: %v", err)
 	}
@@ -139,46 +97,11 @@ func (l C02L02) getContext(facts []string) string {
 	return fmt.Sprintf("\nContext```%s```", strBuilder.String())
 }
 
-func (l C02L02) moderatedChat(system, user, assistant string) (string, error) {
-	moderationRequired, err := l.moderator.Moderate(context.Background(), system)
-	if err != nil {
-		return "", fmt.Errorf("failed to moderate entry: %v", err)
-	}
-	if moderationRequired {
-		return "", fmt.Errorf("entry breaks openai usage policies: %s", system)
-	}
-	resp, err := l.completeChat(system, user, assistant)
+func (l C02L02) moderatedChat(ctx context.Context, system, user string) (string, error) {
+	conv := ai.NewConversation(system).AddUser(user)
+	resp, err := l.chat.ModeratedComplete(ctx, conv)
 	if err != nil {
 		return "", fmt.Errorf("failed to complete moderated chat: %v", err)
 	}
 	return resp, nil
 }
-
-func (l C02L02) completeChat(system, user, assistant string) (string, error) {
-	req := openai.ChatCompletionRequest{
-		Model: openai.GPT3Dot5Turbo,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: system,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: user,
-			},
-		},
-		MaxTokens:   250,
-		Temperature: 0,
-		TopP:        1,
-		N:           1,
-		Stream:      false,
-	}
-	resp, err := l.completor.CreateChatCompletion(context.Background(), req)
-	if err != nil {
-		return "", fmt.Errorf("response failure for chat completion: %v", err)
-	}
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("empty response received")
-	}
-	return resp.Choices[0].Message.Content, nil
-}
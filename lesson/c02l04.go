@@ -6,79 +6,42 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/koenno/aidevs2/moderation"
+	"github.com/koenno/aidevs2/resilience"
 	"github.com/sashabaranov/go-openai"
 )
 
-func init() {
-	registry["c02l04"] = C02L04Creator{}
-}
-
-type C02L04Creator struct {
-}
-
-func (c C02L04Creator) Create(openaiKey string) TaskSolver {
-	client := openai.NewClient(openaiKey)
-	return C02L04{
-		transcriptor: client,
-		moderator: moderation.Moderator{
-			OpenAIMod: client,
-		},
-		taskName: "whisper",
-	}
-}
+var (
+	downloadClient = resilience.NewClient(2 * time.Minute)
+)
 
-type Transcriptor interface {
-	CreateTranscription(context.Context, openai.AudioRequest) (openai.AudioResponse, error)
+func init() {
+	Define("c02l04", "whisper", func(ctx context.Context, task C02L04Task, deps Deps) (C02L04Solution, error) {
+		l := C02L04{
+			transcriptor: deps.Provider,
+			moderator:    deps.Moderator(),
+		}
+		return l.getSolution(ctx, task)
+	}, Describe("Transcribe the linked audio file"), Requires(ServiceOpenAI, ServiceWeb), Models(openai.Whisper1))
 }
 
 type C02L04 struct {
 	transcriptor Transcriptor
 	moderator    Moderator
-	taskName     string
 }
 
 type C02L04Task struct {
-	Task
-}
-
-func (t *C02L04Task) GetCode() int {
-	return t.Code
-}
-
-func (t *C02L04Task) GetMsg() string {
-	return t.Msg
-}
-
-func (t *C02L04Task) SetToken(token string) {
-	t.Token = token
+	Msg string `json:"msg"`
 }
 
 type C02L04Solution string
 
-func (l C02L04) Solve(server TaskServer) error {
-	var task C02L04Task
-	err := server.FetchTask(l.taskName, &task)
-	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
-	}
-	solution, err := l.getSolution(task)
-	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
-	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
-	}
-	return nil
-}
-
-func (l C02L04) getSolution(task C02L04Task) (C02L04Solution, error) {
+func (l C02L04) getSolution(ctx context.Context, task C02L04Task) (C02L04Solution, error) {
 	const phrase = "please return transcription of this file: "
 	parts := strings.SplitAfter(task.Msg, phrase)
 	fileURL := parts[len(parts)-1]
-	text, err := l.transcript(fileURL)
+	text, err := l.transcript(ctx, fileURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to transcribe: %v", err)
 	}
@@ -86,8 +49,12 @@ func (l C02L04) getSolution(task C02L04Task) (C02L04Solution, error) {
 	return C02L04Solution(text), nil
 }
 
-func (l C02L04) transcript(URL string) (string, error) {
-	resp, err := http.Get(URL)
+func (l C02L04) transcript(ctx context.Context, URL string) (string, error) {
+	downloadReq, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create download request: %v", err)
+	}
+	resp, err := downloadClient.Do(downloadReq)
 	if err != nil {
 		return "", fmt.Errorf("download failure: %v", err)
 	}
@@ -105,7 +72,7 @@ func (l C02L04) transcript(URL string) (string, error) {
 		Language:    "pl",
 		Format:      openai.AudioResponseFormatJSON,
 	}
-	transResp, err := l.transcriptor.CreateTranscription(context.Background(), req)
+	transResp, err := l.transcriptor.CreateTranscription(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("transcription error: %v", err)
 	}
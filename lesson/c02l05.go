@@ -1,65 +1,24 @@
 package lesson
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
 func init() {
-	registry["c02l05"] = C02L05Creator{}
-}
-
-type C02L05Creator struct {
-}
-
-func (c C02L05Creator) Create(openaiKey string) TaskSolver {
-	return C02L05{
-		taskName: "functions",
-	}
+	Define("c02l05", "functions", func(ctx context.Context, task struct{}, deps Deps) (C02L05Solution, error) {
+		return C02L05{}.getSolution(ctx, task)
+	}, Describe("Return the definition of the addUser function"))
 }
 
 type C02L05 struct {
-	taskName string
-}
-
-type C02L05Task struct {
-	Task
-}
-
-func (t *C02L05Task) GetCode() int {
-	return t.Code
-}
-
-func (t *C02L05Task) GetMsg() string {
-	return t.Msg
-}
-
-func (t *C02L05Task) SetToken(token string) {
-	t.Token = token
 }
 
 type C02L05Solution openai.FunctionDefinition
 
-func (l C02L05) Solve(server TaskServer) error {
-	var task Lesson01Task
-	err := server.FetchTask(l.taskName, &task)
-	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
-	}
-	solution, err := l.getSolution(task)
-	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
-	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
-	}
-	return nil
-}
-
-func (l C02L05) getSolution(task Lesson01Task) (C02L05Solution, error) {
+func (l C02L05) getSolution(ctx context.Context, task struct{}) (C02L05Solution, error) {
 	return C02L05Solution(openai.FunctionDefinition{
 		Name:        "addUser",
 		Description: "Add user to the system",
@@ -0,0 +1,60 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyScraper answers with failures before the page comes
+type flakyScraper struct {
+	failures int
+	calls    int
+}
+
+func (s *flakyScraper) Send(r *http.Request) (string, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return "", errors.New("failure response: server overloaded")
+	}
+	return "Pizza Margherita pochodzi z Neapolu.", nil
+}
+
+func TestShouldRetryScrapingUntilPageComes(t *testing.T) {
+	// given
+	scraper := &flakyScraper{failures: 2}
+	provider := ai.NewScripted(ai.Script{Chat: []ai.ScriptedReply{{Match: "pochodzi z Neapolu", Content: "z Neapolu"}}})
+	sut := C03L02{
+		chat:          ai.NewChat(provider),
+		scraperClient: scraper,
+	}
+
+	// when
+	solution, err := sut.getSolution(context.Background(), C03L02Task{Input: "http://article", Question: "Skąd pochodzi pizza?"})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, C03L02Solution("z Neapolu"), solution)
+	assert.Equal(t, 3, scraper.calls)
+}
+
+func TestShouldGiveUpScrapingAfterRetries(t *testing.T) {
+	// given
+	scraper := &flakyScraper{failures: C03L02Retries + 1}
+	sut := C03L02{
+		chat:          ai.NewChat(ai.NewScripted(ai.Script{})),
+		scraperClient: scraper,
+	}
+
+	// when
+	_, err := sut.getSolution(context.Background(), C03L02Task{Input: "http://article", Question: "Skąd pochodzi pizza?"})
+
+	// then
+	assert.ErrorContains(t, err, "server overloaded")
+	assert.Equal(t, C03L02Retries+1, scraper.calls)
+}
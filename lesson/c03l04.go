@@ -8,41 +8,37 @@ import (
 	"os"
 
 	"github.com/google/uuid"
+	"github.com/koenno/aidevs2/ai"
 	"github.com/koenno/aidevs2/embedding"
-	"github.com/koenno/aidevs2/moderation"
+	"github.com/koenno/aidevs2/retrieval"
 	"github.com/koenno/aidevs2/vectordb"
 	"github.com/sashabaranov/go-openai"
 )
 
-const C03L04CollectionName = "aidevs2_c03l04"
+const (
+	C03L04CollectionName = "aidevs2_c03l04"
+	C03L04ArchivePath    = "data/c03l04/archiwum.json"
+	// C03L04MinScore is the similarity below which an article is not considered relevant to the question
+	C03L04MinScore = 0.8
+	// C03L04RerankTop is how many of the best articles are judged by the model
+	C03L04RerankTop = 5
+)
 
 func init() {
-	registry["c03l04"] = C03L04Creator{}
-}
-
-type C03L04Creator struct {
-}
-
-func (c C03L04Creator) Create(openaiKey string) TaskSolver {
-	db, err := vectordb.New("localhost:6334")
-	if err != nil {
-		log.Fatalf("failed to create vector db: %v", err)
-	}
-	client := openai.NewClient(openaiKey)
-	return C03L04{
-		embeddor: embedding.Embeddor{
-			Client: client,
-			Moderator: moderation.Moderator{
-				OpenAIMod: client,
-			},
-		},
-		db:       db,
-		taskName: "search",
-	}
-}
-
-type ModeratedEmbeddor interface {
-	ModeratedEmbedding(ctx context.Context, text string) ([]float32, error)
+	Define("c03l04", "search", func(ctx context.Context, task C03L04Task, deps Deps) (C03L04Solution, error) {
+		db, err := deps.VectorDB()
+		if err != nil {
+			return "", err
+		}
+		l := C03L04{
+			embeddor:    deps.Embeddor(),
+			db:          db,
+			archivePath: C03L04ArchivePath,
+			minScore:    C03L04MinScore,
+			judge:       retrieval.NewReranker(deps.Chat(ai.WithModel(deps.Config.Models.Tools))),
+		}
+		return l.getSolution(ctx, task)
+	}, Describe("Find the article URL with hybrid keyword and semantic search over the news archive"), Requires(ServiceOpenAI, ServiceVectorDB), Models(ModelTools, string(openai.AdaEmbeddingV2)))
 }
 
 type VectorDB interface {
@@ -53,47 +49,19 @@ type VectorDB interface {
 }
 
 type C03L04 struct {
-	embeddor ModeratedEmbeddor
-	db       VectorDB
-	taskName string
+	embeddor    BatchEmbeddor
+	db          VectorDB
+	archivePath string
+	minScore    float32
+	judge       retrieval.Judge
 }
 
 type C03L04Task struct {
-	Task
 	Question string `json:"question"`
 }
 
-func (t *C03L04Task) GetCode() int {
-	return t.Code
-}
-
-func (t *C03L04Task) GetMsg() string {
-	return t.Msg
-}
-
-func (t *C03L04Task) SetToken(token string) {
-	t.Token = token
-}
-
 type C03L04Solution string
 
-func (l C03L04) Solve(server TaskServer) error {
-	var task C03L04Task
-	err := server.FetchTask(l.taskName, &task)
-	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
-	}
-	solution, err := l.getSolution(task)
-	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
-	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
-	}
-	return nil
-}
-
 type ArchiveEntry struct {
 	Title string `json:"title" qdrant:"title"`
 	URL   string `json:"url" qdrant:"url"`
@@ -108,11 +76,11 @@ type ArchiveEntity struct {
 	URL    string    `qdrant:"url"`
 	Info   string    `qdrant:"info"`
 	Date   string    `qdrant:"date"`
+	Score  float32   `qdrant:"_score"`
 }
 
-func (l C03L04) getSolution(task C03L04Task) (C03L04Solution, error) {
-	const filePath = "data/c03l04/small_archiwum1.json"
-	// const filePath = "data/c03l04/test.json"
+func (l C03L04) getSolution(ctx context.Context, task C03L04Task) (C03L04Solution, error) {
+	filePath := l.archivePath
 	f, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file '%s': %v", filePath, err)
@@ -123,30 +91,29 @@ func (l C03L04) getSolution(task C03L04Task) (C03L04Solution, error) {
 		}
 	}()
 
-	ctx := context.Background()
 	exist, err := l.db.CollectionExist(ctx, C03L04CollectionName)
 	if err != nil {
 		return "", fmt.Errorf("failed to check collection presence: %v", err)
 	}
+	var archive []ArchiveEntry
+	if err := json.NewDecoder(f).Decode(&archive); err != nil {
+		return "", fmt.Errorf("failed to decode file content '%s': %v", filePath, err)
+	}
+	if len(archive) == 0 {
+		return "", fmt.Errorf("no archive entries found")
+	}
 	if !exist {
 		if err := l.db.CreateCollection(ctx, C03L04CollectionName); err != nil {
 			return "", fmt.Errorf("failed to create collection: %v", err)
 		}
 		log.Printf("collection '%s' created", C03L04CollectionName)
-		var archive []ArchiveEntry
-		if err := json.NewDecoder(f).Decode(&archive); err != nil {
-			return "", fmt.Errorf("failed to decode file content '%s': %v", filePath, err)
-		}
-		if len(archive) == 0 {
-			return "", fmt.Errorf("no archive entries found")
-		}
 		if err := l.storeEntries(ctx, archive); err != nil {
 			return "", fmt.Errorf("failed to store entries: %v", err)
 		}
 		log.Printf("all entries stored")
 	}
 
-	answer, err := l.findAnswer(ctx, task.Question)
+	answer, err := l.findAnswer(ctx, task.Question, archive)
 	if err != nil {
 		return "", fmt.Errorf("failed to find answer for question '%s': %v", task.Question, err)
 	}
@@ -158,18 +125,25 @@ func (l C03L04) getSolution(task C03L04Task) (C03L04Solution, error) {
 }
 
 func (l C03L04) storeEntries(ctx context.Context, entries []ArchiveEntry) error {
-	var entities []any
-	for _, entry := range entries {
-		embedding, err := l.embeddor.ModeratedEmbedding(ctx, entry.Title)
-		if err != nil {
-			return fmt.Errorf("failed to create embedding for entry '%s': %v", entry.Title, err)
-		}
-		if len(embedding) == 0 {
+	titles := make([]string, len(entries))
+	for i, entry := range entries {
+		titles[i] = entry.Title
+	}
+	embeddings, err := l.embeddor.ModeratedEmbeddings(ctx, titles, embedding.WithProgress(func(done, total int) {
+		log.Printf("embedded %d/%d entries", done, total)
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings: %v", err)
+	}
+	entities := make([]any, 0, len(entries))
+	for i, entry := range entries {
+		vector := embeddings[i]
+		if len(vector) == 0 {
 			return fmt.Errorf("no embedding for entry '%s'", entry.Title)
 		}
 		entity := ArchiveEntity{
 			ID:     uuid.NewString(),
-			Vector: embedding,
+			Vector: vector,
 			Title:  entry.Title,
 			URL:    entry.URL,
 			Info:   entry.Info,
@@ -178,29 +152,40 @@ func (l C03L04) storeEntries(ctx context.Context, entries []ArchiveEntry) error
 		entities = append(entities, entity)
 	}
 	log.Printf("embeddings created")
-	err := l.db.UpsertMany(context.Background(), C03L04CollectionName, entities)
+	err = l.db.UpsertMany(ctx, C03L04CollectionName, entities)
 	if err != nil {
 		return fmt.Errorf("failed to upsert archive entity: %v", err)
 	}
 	return nil
 }
 
-func (l C03L04) findAnswer(ctx context.Context, question string) (string, error) {
+// findAnswer combines keyword search over titles, info and dates with similarity of titles, so exact names and dates
+// are found even when the title alone is not similar enough to the question
+func (l C03L04) findAnswer(ctx context.Context, question string, entries []ArchiveEntry) (string, error) {
 	log.Printf("finding answer")
-	embedding, err := l.embeddor.ModeratedEmbedding(ctx, question)
-	if err != nil {
-		return "", fmt.Errorf("failed to create embedding for question '%s': %v", question, err)
+	opts := []retrieval.Option{
+		retrieval.WithKeyField("url"),
+		retrieval.WithVectorOptions(vectordb.WithScoreThreshold(l.minScore)),
+	}
+	if l.judge != nil {
+		opts = append(opts, retrieval.WithReranking(l.judge, C03L04RerankTop))
+	}
+	retriever := retrieval.NewHybrid[ArchiveEntity](l.db, l.embeddor, C03L04CollectionName, []string{"title", "info", "date"}, opts...)
+	entities := make([]ArchiveEntity, len(entries))
+	for i, entry := range entries {
+		entities[i] = ArchiveEntity{Title: entry.Title, URL: entry.URL, Info: entry.Info, Date: entry.Date}
 	}
-	if len(embedding) == 0 {
-		return "", fmt.Errorf("no embedding for question '%s'", question)
+	if err := retriever.Index(entities); err != nil {
+		return "", fmt.Errorf("failed to index archive entries: %v", err)
 	}
-	var entities []ArchiveEntity
-	err = l.db.Search(ctx, C03L04CollectionName, embedding, &entities, vectordb.WithLimit(1))
+	results, err := retriever.Search(ctx, question, 1)
 	if err != nil {
 		return "", fmt.Errorf("failed to find answer: %v", err)
 	}
-	if len(entities) == 0 {
-		return "", fmt.Errorf("no answer found %#v", entities)
+	if len(results) == 0 {
+		return "", fmt.Errorf("no relevant article found")
 	}
-	return entities[0].URL, nil
+	best := results[0]
+	log.Printf("article '%s' ranked %.4f", best.Item.Title, best.Score)
+	return best.Item.URL, nil
 }
@@ -0,0 +1,87 @@
+package lesson
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/retrieval"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldFindArticleWithInMemoryVectorDB(t *testing.T) {
+	// given
+	sut := newC03L04InMemory(t, 0.3)
+
+	// when
+	solution, err := sut.getSolution(context.Background(), C03L04Task{Question: "Czym różni się pseudonimizacja od anonimizacja danych?"})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, C03L04Solution("https://example.com/rodo"), solution)
+}
+
+func TestShouldFindArticleByKeywordOfInfo(t *testing.T) {
+	// given
+	sut := newC03L04InMemory(t, 0.99)
+
+	// when
+	solution, err := sut.getSolution(context.Background(), C03L04Task{Question: "Który artykuł dotyczy DevOps?"})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, C03L04Solution("https://example.com/k8s"), solution)
+}
+
+func TestShouldFailWhenNoArticleIsRelevantEnough(t *testing.T) {
+	// given
+	sut := newC03L04InMemory(t, 0.99)
+
+	// when
+	_, err := sut.getSolution(context.Background(), C03L04Task{Question: "Ile kosztuje bilet do Tokio?"})
+
+	// then
+	assert.ErrorContains(t, err, "no relevant article found")
+}
+
+func TestShouldFindArticleJudgedMostRelevant(t *testing.T) {
+	// given
+	sut := newC03L04InMemory(t, 0.3)
+	chat := ai.NewChat(ai.NewScripted(ai.Script{Chat: []ai.ScriptedReply{
+		{Match: "Passages:", Call: &openai.FunctionCall{Name: "respond", Arguments: `{"ranking": [2]}`}},
+	}}))
+	sut.judge = retrieval.NewReranker(chat)
+
+	// when
+	solution, err := sut.getSolution(context.Background(), C03L04Task{Question: "Anonimizacja danych czy przepis na pizzę?"})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, C03L04Solution("https://example.com/rodo"), solution, "the pizza article is fused first")
+}
+
+func newC03L04InMemory(t *testing.T, minScore float32) C03L04 {
+	archive := filepath.Join(t.TempDir(), "archive.json")
+	require.NoError(t, os.WriteFile(archive, []byte(`[
+		{"title": "Jak działa pseudonimizacja i anonimizacja danych", "url": "https://example.com/rodo", "info": "RODO", "date": "2023-01-01"},
+		{"title": "Przepis na pizzę neapolitańską", "url": "https://example.com/pizza", "info": "kuchnia", "date": "2023-01-02"},
+		{"title": "Kubernetes w małej firmie", "url": "https://example.com/k8s", "info": "devops", "date": "2023-01-03"}
+	]`), 0o644))
+	cfg := config.Default()
+	cfg.VectorDB.Backend = config.VectorDBMemory
+	container := NewContainer(ai.NewScripted(ai.Script{}), cfg)
+	t.Cleanup(container.Close)
+	db, err := container.VectorDB()
+	require.NoError(t, err)
+	return C03L04{
+		embeddor:    container.Embeddor(),
+		db:          db,
+		archivePath: archive,
+		minScore:    minScore,
+	}
+}
@@ -10,8 +10,6 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/koenno/aidevs2/ai"
-	"github.com/koenno/aidevs2/embedding"
-	"github.com/koenno/aidevs2/moderation"
 	"github.com/koenno/aidevs2/nosqldb"
 	"github.com/sashabaranov/go-openai"
 )
@@ -32,34 +30,20 @@ Strict rules of this conversation:
 )
 
 func init() {
-	registry["c03l05"] = C03L05Creator{}
-}
-
-type C03L05Creator struct {
-}
-
-func (c C03L05Creator) Create(openaiKey string) TaskSolver {
-	noSQLDB, err := nosqldb.New("localhost:27017")
-	if err != nil {
-		log.Fatalf("failed to create no sql db: %v", err)
-	}
-
-	client := openai.NewClient(openaiKey)
-	return C03L05{
-		chat: ai.NewChat(openaiKey, ai.WithModel(openai.GPT40613)),
-		embeddor: embedding.Embeddor{
-			Client: client,
-			Moderator: moderation.Moderator{
-				OpenAIMod: client,
-			},
-		},
-		noSQLDB:  noSQLDB,
-		taskName: "people",
-	}
-}
-
-type AIChat interface {
-	ModeratedChat(system string, userMsgs ...string) (string, error)
+	Define("c03l05", "people", func(ctx context.Context, task C03L05Task, deps Deps) (C03L05Solution, error) {
+		noSQLDB, err := deps.NoSQLDB()
+		if err != nil {
+			return "", err
+		}
+		chat := deps.Chat(ai.WithModel(deps.Config.Models.Tools), ai.WithBudget(ai.TruncateLongest()))
+		l := C03L05{
+			chat:       chat,
+			structurer: chat,
+			embeddor:   deps.Embeddor(),
+			noSQLDB:    noSQLDB,
+		}
+		return l.getSolution(ctx, task)
+	}, Describe("Answer a question about a person from the people database"), Requires(ServiceOpenAI, ServiceNoSQLDB), Models(ModelTools, string(openai.AdaEmbeddingV2)))
 }
 
 type NoSQLDB interface {
@@ -69,48 +53,18 @@ type NoSQLDB interface {
 }
 
 type C03L05 struct {
-	chat     AIChat
-	embeddor ModeratedEmbeddor
-	noSQLDB  NoSQLDB
-	taskName string
+	chat       AIChat
+	structurer AIStructurer
+	embeddor   ModeratedEmbeddor
+	noSQLDB    NoSQLDB
 }
 
 type C03L05Task struct {
-	Task
 	Question string `json:"question"`
 }
 
-func (t *C03L05Task) GetCode() int {
-	return t.Code
-}
-
-func (t *C03L05Task) GetMsg() string {
-	return t.Msg
-}
-
-func (t *C03L05Task) SetToken(token string) {
-	t.Token = token
-}
-
 type C03L05Solution string
 
-func (l C03L05) Solve(server TaskServer) error {
-	var task C03L05Task
-	err := server.FetchTask(l.taskName, &task)
-	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
-	}
-	solution, err := l.getSolution(task)
-	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
-	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
-	}
-	return nil
-}
-
 type Person struct {
 	ID                    string `bson:"_id" qdrant:"_id" json:"-"`
 	Name                  string `bson:"name" qdrant:"name" json:"imie"`
@@ -123,7 +77,7 @@ type Person struct {
 	Color                 string `bson:"color" qdrant:"color" json:"ulubiony_kolor"`
 }
 
-func (l C03L05) getSolution(task C03L05Task) (C03L05Solution, error) {
+func (l C03L05) getSolution(ctx context.Context, task C03L05Task) (C03L05Solution, error) {
 	const filePath = "data/c03l05/people.json"
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -135,7 +89,6 @@ func (l C03L05) getSolution(task C03L05Task) (C03L05Solution, error) {
 		}
 	}()
 
-	ctx := context.Background()
 	exist, err := l.collectionsExist(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to check collection presence: %v", err)
@@ -211,24 +164,32 @@ func (l C03L05) findAnswer(ctx context.Context, question string) (string, error)
 	return l.getAnswerByAI(ctx, person, question)
 }
 
+type PersonName struct {
+	Name    string `json:"name" description:"The first name of the person"`
+	Surname string `json:"surname" description:"The surname of the person"`
+}
+
+func (p *PersonName) Validate() error {
+	if strings.ContainsAny(p.Name, " .") || strings.ContainsAny(p.Surname, " .") {
+		return fmt.Errorf("name and surname should be single words")
+	}
+	return nil
+}
+
 func (l C03L05) getPersonName(ctx context.Context, question string) (string, string, error) {
 	system := fmt.Sprintf(`%s
 
 Sentence:	
 "%s"
 `, conversationRules, question)
-	user := "Person name"
-	answer, err := l.chat.ModeratedChat(system, user)
+	conv := ai.NewConversation(system).AddUser("Person name")
+	var person PersonName
+	err := l.structurer.ModeratedCompleteInto(ctx, conv, &person)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to chat: %v", err)
 	}
-	log.Printf("got an answer: %s", answer)
-	answer = strings.ReplaceAll(answer, ".", "")
-	elems := strings.Split(answer, " ")
-	if len(elems) != 2 {
-		return "", "", fmt.Errorf("should get exact 2 words in answer: '%s'", answer)
-	}
-	return elems[0], elems[1], nil
+	log.Printf("got an answer: %v", person)
+	return person.Name, person.Surname, nil
 }
 
 func (l C03L05) getPersonFromDB(ctx context.Context, name, surname string) (Person, error) {
@@ -251,7 +212,7 @@ Context:
 %s"
 `, conversationRules, person.Name, person.Surname, person.AboutMe)
 	user := question
-	answer, err := l.chat.ModeratedChat(system, user)
+	answer, err := l.chat.ModeratedChat(ctx, system, user)
 	if err != nil {
 		return "", fmt.Errorf("failed to chat: %v", err)
 	}
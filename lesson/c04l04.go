@@ -1,61 +1,110 @@
 package lesson
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/ownapi"
 )
 
 func init() {
-	registry["c04l04"] = C04L04Creator{}
+	Define("c04l04", "ownapi", func(ctx context.Context, _ struct{}, deps Deps) (C04L04Solution, error) {
+		l := C04L04{
+			ownAPI: newOwnAPI(deps.Chat(), deps.Config.OwnAPI, deps.Config.AIDevs.URL),
+		}
+		return l.getSolution(ctx, deps)
+	}, Describe("Serve an API answering questions"), Requires(ServiceOpenAI, ServiceOwnAPI), Models(ModelChat))
 }
 
-type C04L04Creator struct {
+type C04L04 struct {
+	ownAPI ownAPI
 }
 
-func (c C04L04Creator) Create(openaiKey string) TaskSolver {
-	return C04L04{
-		taskName: "ownapi",
+type C04L04Solution string
+
+func (l C04L04) getSolution(ctx context.Context, deps Deps) (C04L04Solution, error) {
+	srv, err := l.ownAPI.start()
+	if err != nil {
+		return "", fmt.Errorf("failed to start own api: %v", err)
 	}
+	deps.Defer(func() {
+		l.ownAPI.stop(srv)
+	})
+	apiURL, err := l.ownAPI.url(srv)
+	if err != nil {
+		return "", err
+	}
+	return C04L04Solution(apiURL), nil
 }
 
-type C04L04 struct {
-	taskName string
+// ownAPI runs the question answering server for the time the task is being verified
+type ownAPI struct {
+	chat      ownapi.Chat
+	addr      string
+	publicURL string
+	aidevsURL string
+	opts      []ownapi.Option
 }
 
-type C04L04Task struct {
-	Task
+// newOwnAPI creates the server for the task of the AI Devs server at aidevsURL which is going to call it
+func newOwnAPI(chat ownapi.Chat, cfg config.OwnAPI, aidevsURL string, opts ...ownapi.Option) ownAPI {
+	return ownAPI{
+		chat:      chat,
+		addr:      cfg.Addr,
+		publicURL: cfg.PublicURL,
+		aidevsURL: aidevsURL,
+		opts:      opts,
+	}
 }
 
-func (t *C04L04Task) GetCode() int {
-	return t.Code
+func (a ownAPI) start() (*ownapi.Server, error) {
+	srv, err := ownapi.Listen(a.addr, ownapi.NewHandler(a.chat, a.opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %v", err)
+	}
+	go func() {
+		if err := srv.Serve(); err != nil {
+			log.Printf("own api failure: %v", err)
+		}
+	}()
+	log.Printf("own api listening on %s", srv.URL())
+	return srv, nil
 }
 
-func (t *C04L04Task) GetMsg() string {
-	return t.Msg
+func (a ownAPI) stop(srv *ownapi.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Close(ctx); err != nil {
+		log.Printf("failed to stop own api: %v", err)
+	}
 }
 
-func (t *C04L04Task) SetToken(token string) {
-	t.Token = token
+// url returns the public URL, the local address is good enough only for an AI Devs server running locally like fakeaidevs
+func (a ownAPI) url(srv *ownapi.Server) (string, error) {
+	if a.publicURL != "" {
+		return a.publicURL, nil
+	}
+	if !isLocal(a.aidevsURL) {
+		return "", fmt.Errorf("public URL of own api is not configured, %s can not reach %s", a.aidevsURL, srv.URL())
+	}
+	log.Printf("public URL of own api is not configured, the local address is used")
+	return srv.URL(), nil
 }
 
-type C04L04Solution string
-
-func (l C04L04) Solve(server TaskServer) error {
-	var task C04L04Task
-	err := server.FetchTask(l.taskName, &task)
+func isLocal(rawURL string) bool {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
+		return false
 	}
-	solution, err := l.getSolution(task)
-	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
-	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
 	}
-	return nil
-}
-
-func (l C04L04) getSolution(task C04L04Task) (C04L04Solution, error) {
-	return "https://gocraft.it/answer", nil
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
 }
@@ -0,0 +1,65 @@
+package lesson
+
+import (
+	"testing"
+
+	"github.com/koenno/aidevs2/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldChooseOwnAPIURL(t *testing.T) {
+	testCases := []struct {
+		name      string
+		publicURL string
+		aidevsURL string
+		local     bool
+		err       string
+	}{
+		{
+			name:      "public url",
+			publicURL: "https://some.tunnel/answer",
+			aidevsURL: "https://zadania.aidevs.pl",
+		},
+		{
+			name:      "local aidevs",
+			aidevsURL: "http://localhost:8081",
+			local:     true,
+		},
+		{
+			name:      "loopback aidevs",
+			aidevsURL: "http://127.0.0.1:8081",
+			local:     true,
+		},
+		{
+			name:      "remote aidevs",
+			aidevsURL: "https://zadania.aidevs.pl",
+			err:       "public URL of own api is not configured",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			sut := newOwnAPI(nil, config.OwnAPI{Addr: "127.0.0.1:0", PublicURL: tc.publicURL}, tc.aidevsURL)
+			srv, err := sut.start()
+			require.NoError(t, err)
+			defer sut.stop(srv)
+
+			// when
+			apiURL, err := sut.url(srv)
+
+			// then
+			switch {
+			case tc.err != "":
+				assert.ErrorContains(t, err, tc.err)
+			case tc.local:
+				assert.NoError(t, err)
+				assert.Equal(t, srv.URL(), apiURL)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tc.publicURL, apiURL)
+			}
+		})
+	}
+}
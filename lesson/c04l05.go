@@ -1,61 +1,38 @@
 package lesson
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/koenno/aidevs2/ownapi"
 )
 
 func init() {
-	registry["c04l05"] = C04L05Creator{}
-}
-
-type C04L05Creator struct {
-}
-
-func (c C04L05Creator) Create(openaiKey string) TaskSolver {
-	return C04L05{
-		taskName: "ownapipro",
-	}
+	Define("c04l05", "ownapipro", func(ctx context.Context, _ struct{}, deps Deps) (C04L05Solution, error) {
+		l := C04L05{
+			ownAPI: newOwnAPI(deps.Chat(), deps.Config.OwnAPI, deps.Config.AIDevs.URL, ownapi.WithMemory()),
+		}
+		return l.getSolution(ctx, deps)
+	}, Describe("Serve an API answering questions and remembering facts"), Requires(ServiceOpenAI, ServiceOwnAPI), Models(ModelChat))
 }
 
 type C04L05 struct {
-	taskName string
-}
-
-type C04L05Task struct {
-	Task
-}
-
-func (t *C04L05Task) GetCode() int {
-	return t.Code
-}
-
-func (t *C04L05Task) GetMsg() string {
-	return t.Msg
-}
-
-func (t *C04L05Task) SetToken(token string) {
-	t.Token = token
+	ownAPI ownAPI
 }
 
 type C04L05Solution string
 
-func (l C04L05) Solve(server TaskServer) error {
-	var task C04L05Task
-	err := server.FetchTask(l.taskName, &task)
+func (l C04L05) getSolution(ctx context.Context, deps Deps) (C04L05Solution, error) {
+	srv, err := l.ownAPI.start()
 	if err != nil {
-		return fmt.Errorf("failed to fetch task: %v", err)
+		return "", fmt.Errorf("failed to start own api: %v", err)
 	}
-	solution, err := l.getSolution(task)
+	deps.Defer(func() {
+		l.ownAPI.stop(srv)
+	})
+	apiURL, err := l.ownAPI.url(srv)
 	if err != nil {
-		return fmt.Errorf("failed to find solution: %v", err)
+		return "", err
 	}
-	err = server.SendSolution(task.Token, solution)
-	if err != nil {
-		return fmt.Errorf("failed to send solution: %v", err)
-	}
-	return nil
-}
-
-func (l C04L05) getSolution(task C04L05Task) (C04L05Solution, error) {
-	return "https://gocraft.it/answer", nil
+	return C04L05Solution(apiURL), nil
 }
@@ -0,0 +1,323 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/embedding"
+	"github.com/koenno/aidevs2/moderation"
+	"github.com/koenno/aidevs2/nosqldb"
+	"github.com/koenno/aidevs2/task"
+	"github.com/koenno/aidevs2/vectordb"
+)
+
+// SolveFunc finds the solution of the task described by the payload
+type SolveFunc[P, S any] func(ctx context.Context, payload P, deps Deps) (S, error)
+
+// RetryFunc corrects a rejected solution with the verdict carrying the message and hint of the task server,
+// it returns false when it gives up
+type RetryFunc[P, S any] func(ctx context.Context, payload P, verdict task.Verdict, deps Deps) (S, bool, error)
+
+// DefineOption adds metadata or behaviour to a lesson definition
+type DefineOption func(*definitionOptions)
+
+type definitionOptions struct {
+	info    Info
+	retry   any
+	retries int
+}
+
+func Describe(description string) DefineOption {
+	return func(o *definitionOptions) {
+		o.info.Description = description
+	}
+}
+
+// Alias lets the lesson be found by other names too
+func Alias(names ...string) DefineOption {
+	return func(o *definitionOptions) {
+		o.info.Aliases = append(o.info.Aliases, names...)
+	}
+}
+
+func Requires(services ...Service) DefineOption {
+	return func(o *definitionOptions) {
+		o.info.Services = append(o.info.Services, services...)
+	}
+}
+
+// Models lists models the solver uses, roles like ModelChat are resolved with the config
+func Models(models ...string) DefineOption {
+	return func(o *definitionOptions) {
+		o.info.Models = append(o.info.Models, models...)
+	}
+}
+
+// WithRetry lets the solver correct a rejected solution at most retries times, P and S must match the solve function
+func WithRetry[P, S any](retries int, retry RetryFunc[P, S]) DefineOption {
+	return func(o *definitionOptions) {
+		o.retry = retry
+		o.retries = retries
+	}
+}
+
+// Define registers a solver for the lesson, the task is fetched into P before solve is called and its solution is sent afterwards
+func Define[P, S any](id, taskName string, solve SolveFunc[P, S], opts ...DefineOption) {
+	o := definitionOptions{
+		info: Info{
+			ID:       id,
+			TaskName: taskName,
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	d := definition[P, S]{
+		name:     id,
+		taskName: taskName,
+		solve:    solve,
+	}
+	if o.retry != nil {
+		retry, ok := o.retry.(RetryFunc[P, S])
+		if !ok {
+			panic(fmt.Sprintf("lesson %s: retry of %T does not match the solve function", id, o.retry))
+		}
+		d.retry = retry
+		d.retries = o.retries
+	}
+	register(o.info, d)
+}
+
+type definition[P, S any] struct {
+	name     string
+	taskName string
+	solve    SolveFunc[P, S]
+	retry    RetryFunc[P, S]
+	retries  int
+}
+
+func (d definition[P, S]) Create(container *Container) TaskSolver {
+	return pipeline[P, S]{
+		definition: d,
+		container:  container,
+	}
+}
+
+type pipeline[P, S any] struct {
+	definition[P, S]
+	container *Container
+}
+
+func (p pipeline[P, S]) Solve(ctx context.Context, server TaskServer) error {
+	start := time.Now()
+	t, err := fetchTask[P](ctx, server, p.taskName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch task: %w", err)
+	}
+	log.Printf("lesson %s: fetched task %s: %s", p.name, p.taskName, t.Msg)
+	deps := Deps{
+		Container: p.container,
+		Server:    server,
+		TaskName:  p.taskName,
+		Token:     t.Token,
+		cleanups:  &cleanups{},
+		latest:    &latestToken{token: t.Token},
+	}
+	defer deps.close()
+	solution, err := p.solve(ctx, t.Payload, deps)
+	if err != nil {
+		return fmt.Errorf("failed to find solution: %w", err)
+	}
+	log.Printf("lesson %s: solution found in %v", p.name, time.Since(start).Round(time.Millisecond))
+	verdict, err := server.SendSolution(ctx, deps.latest.get(), solution)
+	for attempt := 0; p.retry != nil && attempt < p.retries && errors.Is(err, task.ErrRejected); attempt++ {
+		log.Printf("lesson %s: solution rejected: %s", p.name, verdict.Feedback())
+		corrected, ok, retryErr := p.retry(ctx, t.Payload, verdict, deps)
+		if retryErr != nil {
+			return fmt.Errorf("failed to correct solution: %w", retryErr)
+		}
+		if !ok {
+			break
+		}
+		verdict, err = server.SendSolution(ctx, deps.latest.get(), corrected)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send solution: %w", err)
+	}
+	log.Printf("lesson %s: solved in %v", p.name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Container shares clients between solvers, clients are created on the first use and closed by Close
+type Container struct {
+	Provider ai.Provider
+	Config   config.Config
+	mu       sync.Mutex
+	vectorDB vectorDBCloser
+	noSQLDB  *nosqldb.DB
+}
+
+type vectorDBCloser interface {
+	VectorDB
+	Close()
+}
+
+func NewContainer(provider ai.Provider, cfg config.Config) *Container {
+	return &Container{
+		Provider: provider,
+		Config:   cfg,
+	}
+}
+
+// Chat creates a chat with the configured chat model, opts may override it
+func (c *Container) Chat(opts ...ai.Option) *ai.Chat {
+	opts = append([]ai.Option{ai.WithModel(c.Config.Models.Chat)}, opts...)
+	return ai.NewChat(c.Provider, opts...)
+}
+
+func (c *Container) Visioner() *ai.Vision {
+	return ai.NewVisioner(c.Provider)
+}
+
+func (c *Container) Moderator() moderation.Moderator {
+	return moderation.Moderator{
+		OpenAIMod: c.Provider,
+	}
+}
+
+func (c *Container) Embeddor() embedding.Embeddor {
+	return embedding.Embeddor{
+		Client:    c.Provider,
+		Moderator: c.Moderator(),
+	}
+}
+
+// VectorDB returns the vector database of the configured backend
+func (c *Container) VectorDB() (VectorDB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.vectorDB == nil {
+		db, err := newVectorDB(c.Config.VectorDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create vector db: %v", err)
+		}
+		c.vectorDB = db
+	}
+	return c.vectorDB, nil
+}
+
+func newVectorDB(cfg config.VectorDB) (vectorDBCloser, error) {
+	switch cfg.Backend {
+	case config.VectorDBQdrant, "":
+		return vectordb.New(cfg.Addr, vectordb.WithBatchSize(cfg.BatchSize))
+	case config.VectorDBMemory:
+		var opts []vectordb.MemoryOption
+		if cfg.Path != "" {
+			opts = append(opts, vectordb.WithFile(cfg.Path))
+		}
+		return vectordb.NewMemory(opts...)
+	}
+	return nil, fmt.Errorf("unsupported vector db backend %s", cfg.Backend)
+}
+
+func (c *Container) NoSQLDB() (*nosqldb.DB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.noSQLDB == nil {
+		db, err := nosqldb.New(c.Config.NoSQLDB.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create no sql db: %v", err)
+		}
+		c.noSQLDB = db
+	}
+	return c.noSQLDB, nil
+}
+
+// Close closes the clients created so far
+func (c *Container) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.vectorDB != nil {
+		c.vectorDB.Close()
+		c.vectorDB = nil
+	}
+	if c.noSQLDB != nil {
+		c.noSQLDB.Close()
+		c.noSQLDB = nil
+	}
+}
+
+// Deps are dependencies of a single solver run
+type Deps struct {
+	*Container
+	Server   TaskServer
+	TaskName string
+	Token    string
+	cleanups *cleanups
+	latest   *latestToken
+}
+
+type cleanups struct {
+	mu    sync.Mutex
+	funcs []func()
+}
+
+// latestToken is the token of the most recent fetch, the solution is sent with it
+type latestToken struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (t *latestToken) get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
+
+func (t *latestToken) set(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = token
+}
+
+// Refetch fetches the task again, e.g. to get another hint, questions and the solution then use the new token
+func Refetch[P any](ctx context.Context, deps *Deps) (P, error) {
+	t, err := fetchTask[P](ctx, deps.Server, deps.TaskName)
+	if err != nil {
+		var zero P
+		return zero, err
+	}
+	deps.Token = t.Token
+	if deps.latest != nil {
+		deps.latest.set(t.Token)
+	}
+	return t.Payload, nil
+}
+
+// AskQuestion asks the task server a question in scope of the fetched task
+func (d Deps) AskQuestion(ctx context.Context, question string) (string, error) {
+	return d.Server.AskQuestion(ctx, d.Token, question)
+}
+
+// Defer runs f after the solution is sent, e.g. to stop a server the task server verifies the solution against
+func (d Deps) Defer(f func()) {
+	d.cleanups.mu.Lock()
+	defer d.cleanups.mu.Unlock()
+	d.cleanups.funcs = append(d.cleanups.funcs, f)
+}
+
+func (d Deps) close() {
+	d.cleanups.mu.Lock()
+	funcs := d.cleanups.funcs
+	d.cleanups.funcs = nil
+	d.cleanups.mu.Unlock()
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i]()
+	}
+}
@@ -0,0 +1,212 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/client/aidevs"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/fakeaidevs"
+	"github.com/koenno/aidevs2/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rejectingTaskServer struct {
+	fakeTaskServer
+}
+
+func (s *rejectingTaskServer) SendSolution(_ context.Context, _ string, _ any) (task.Verdict, error) {
+	return task.Verdict{Code: -777}, task.ErrRejected
+}
+
+// refetchingTaskServer hands out a new token with every fetch and records tokens the task is used with
+type refetchingTaskServer struct {
+	fakeTaskServer
+	fetches int
+	used    []string
+}
+
+func (s *refetchingTaskServer) FetchTask(ctx context.Context, name string, t task.AIDevsTask) error {
+	if err := s.fakeTaskServer.FetchTask(ctx, name, t); err != nil {
+		return err
+	}
+	s.fetches++
+	t.SetToken(fmt.Sprintf("token-%d", s.fetches))
+	return nil
+}
+
+func (s *refetchingTaskServer) AskQuestion(_ context.Context, token, _ string) (string, error) {
+	s.used = append(s.used, "ask "+token)
+	return "some reply", nil
+}
+
+func (s *refetchingTaskServer) SendSolution(ctx context.Context, token string, solution any) (task.Verdict, error) {
+	s.used = append(s.used, "send "+token)
+	return s.fakeTaskServer.SendSolution(ctx, token, solution)
+}
+
+// correctingTaskServer accepts only the expected answer and hints at it when rejecting
+type correctingTaskServer struct {
+	fakeTaskServer
+	expected string
+	sent     []any
+}
+
+func (s *correctingTaskServer) SendSolution(_ context.Context, _ string, solution any) (task.Verdict, error) {
+	s.sent = append(s.sent, solution)
+	if solution != s.expected {
+		return task.Verdict{Code: -777, Message: "Answer is wrong", Extra: map[string]any{"hint": s.expected}}, task.ErrRejected
+	}
+	return task.Verdict{Accepted: true, Message: "OK"}, nil
+}
+
+type failingTaskServer struct {
+	fakeTaskServer
+}
+
+func (s *failingTaskServer) FetchTask(_ context.Context, _ string, _ task.AIDevsTask) error {
+	return aidevs.ErrUnauthorized
+}
+
+func TestShouldRunDefinedSolverPipeline(t *testing.T) {
+	// given
+	type payload struct {
+		Question string `json:"question"`
+	}
+	var events []string
+	t.Cleanup(func() { delete(registry, "test-pipeline") })
+	Define("test-pipeline", "some task", func(ctx context.Context, p payload, deps Deps) (string, error) {
+		events = append(events, "solve "+p.Question+" "+deps.Token)
+		deps.Defer(func() {
+			events = append(events, "cleanup")
+		})
+		return "some answer", nil
+	})
+	server := &fakeTaskServer{fixture: fakeaidevs.Fixture{Task: map[string]any{"question": "some question"}}}
+	sut := CreateTaskSolver("test-pipeline", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	err := sut.Solve(context.Background(), server)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"solve some question some-token", "cleanup"}, events)
+	assert.JSONEq(t, `"some answer"`, string(server.solution))
+}
+
+func TestShouldKeepRejectionOfDefinedSolver(t *testing.T) {
+	// given
+	t.Cleanup(func() { delete(registry, "test-rejected") })
+	Define("test-rejected", "some task", func(ctx context.Context, _ struct{}, deps Deps) (string, error) {
+		return "wrong answer", nil
+	})
+	sut := CreateTaskSolver("test-rejected", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	err := sut.Solve(context.Background(), &rejectingTaskServer{})
+
+	// then
+	assert.ErrorIs(t, err, task.ErrRejected)
+}
+
+func TestShouldCorrectRejectedSolutionWithVerdict(t *testing.T) {
+	// given
+	var feedback []string
+	t.Cleanup(func() { delete(registry, "test-retry") })
+	Define("test-retry", "some task", func(ctx context.Context, _ struct{}, deps Deps) (string, error) {
+		return "wrong answer", nil
+	}, WithRetry(2, func(ctx context.Context, _ struct{}, verdict task.Verdict, deps Deps) (string, bool, error) {
+		feedback = append(feedback, verdict.Feedback())
+		return fmt.Sprint(verdict.Extra["hint"]), true, nil
+	}))
+	server := &correctingTaskServer{expected: "right answer"}
+	sut := CreateTaskSolver("test-retry", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	err := sut.Solve(context.Background(), server)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []any{"wrong answer", "right answer"}, server.sent)
+	require.Len(t, feedback, 1)
+	assert.Contains(t, feedback[0], "right answer")
+}
+
+func TestShouldGiveUpCorrectingAfterRetries(t *testing.T) {
+	// given
+	t.Cleanup(func() { delete(registry, "test-retry-limit") })
+	Define("test-retry-limit", "some task", func(ctx context.Context, _ struct{}, deps Deps) (string, error) {
+		return "wrong answer", nil
+	}, WithRetry(2, func(ctx context.Context, _ struct{}, verdict task.Verdict, deps Deps) (string, bool, error) {
+		return "still wrong", true, nil
+	}))
+	server := &correctingTaskServer{expected: "right answer"}
+	sut := CreateTaskSolver("test-retry-limit", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	err := sut.Solve(context.Background(), server)
+
+	// then
+	assert.ErrorIs(t, err, task.ErrRejected)
+	assert.Len(t, server.sent, 3)
+}
+
+func TestShouldNotSendSolutionWhenSolverFails(t *testing.T) {
+	// given
+	expectedErr := errors.New("some failure")
+	t.Cleanup(func() { delete(registry, "test-failing") })
+	Define("test-failing", "some task", func(ctx context.Context, _ struct{}, deps Deps) (string, error) {
+		return "", expectedErr
+	})
+	server := &fakeTaskServer{}
+	sut := CreateTaskSolver("test-failing", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	err := sut.Solve(context.Background(), server)
+
+	// then
+	assert.ErrorContains(t, err, "some failure")
+	assert.Nil(t, server.solution)
+}
+
+func TestShouldUseTokenOfRefetchedTask(t *testing.T) {
+	// given
+	t.Cleanup(func() { delete(registry, "test-refetch") })
+	Define("test-refetch", "some task", func(ctx context.Context, _ struct{}, deps Deps) (string, error) {
+		if _, err := Refetch[struct{}](ctx, &deps); err != nil {
+			return "", err
+		}
+		_, err := deps.AskQuestion(ctx, "some question")
+		return "some answer", err
+	})
+	server := &refetchingTaskServer{}
+	sut := CreateTaskSolver("test-refetch", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	err := sut.Solve(context.Background(), server)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ask token-2", "send token-2"}, server.used)
+}
+
+func TestShouldKeepSentinelErrorsOfFetchAndSolve(t *testing.T) {
+	// given
+	t.Cleanup(func() { delete(registry, "test-sentinel") })
+	Define("test-sentinel", "some task", func(ctx context.Context, _ struct{}, deps Deps) (string, error) {
+		return "", fmt.Errorf("some failure: %w", task.ErrRejected)
+	})
+	sut := CreateTaskSolver("test-sentinel", NewContainer(ai.NewScripted(ai.Script{}), config.Default()))
+
+	// when
+	fetchErr := sut.Solve(context.Background(), &failingTaskServer{})
+	solveErr := sut.Solve(context.Background(), &fakeTaskServer{})
+
+	// then
+	assert.ErrorIs(t, fetchErr, aidevs.ErrUnauthorized)
+	assert.ErrorIs(t, solveErr, task.ErrRejected)
+}
@@ -0,0 +1,166 @@
+package lesson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/config"
+	"github.com/koenno/aidevs2/fakeaidevs"
+	"github.com/koenno/aidevs2/task"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fixturesDir = "../data/fakeaidevs"
+)
+
+type fakeTaskServer struct {
+	fixture  fakeaidevs.Fixture
+	solution json.RawMessage
+}
+
+func (s *fakeTaskServer) FetchTask(_ context.Context, _ string, task task.AIDevsTask) error {
+	bb, err := json.Marshal(s.fixture.Task)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bb, task); err != nil {
+		return err
+	}
+	task.SetToken("some-token")
+	return nil
+}
+
+func (s *fakeTaskServer) AskQuestion(_ context.Context, _, question string) (string, error) {
+	answer, exist := s.fixture.Questions[question]
+	if !exist {
+		return "", fmt.Errorf("unexpected question %s", question)
+	}
+	return answer, nil
+}
+
+func (s *fakeTaskServer) SendSolution(_ context.Context, _ string, solution any) (task.Verdict, error) {
+	bb, err := json.Marshal(solution)
+	if err != nil {
+		return task.Verdict{}, err
+	}
+	s.solution = bb
+	return task.Verdict{Accepted: true}, nil
+}
+
+func TestShouldSolveLessonsOffline(t *testing.T) {
+	fixtures, err := fakeaidevs.LoadFixtures(fixturesDir)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		lesson string
+		task   string
+		script ai.Script
+	}{
+		{
+			lesson: "1",
+			task:   "helloapi",
+		},
+		{
+			lesson: "4a",
+			task:   "moderation",
+			script: ai.Script{Flagged: []string{"głupi", "zginąć"}},
+		},
+		{
+			lesson: "4b",
+			task:   "blogger",
+			script: ai.Script{Chat: []ai.ScriptedReply{{Content: "Pizza Margherita to klasyka."}}},
+		},
+		{
+			lesson: "c01l05",
+			task:   "liar",
+			script: ai.Script{Chat: []ai.ScriptedReply{{Match: "capital of Poland", Content: "YES"}}},
+		},
+		{
+			lesson: "c02l02",
+			task:   "inprompt",
+			script: ai.Script{Chat: []ai.ScriptedReply{
+				{Match: "give only the name", Content: "Ernest"},
+				{Match: "Ernest jest programistą Go", Content: "Go"},
+			}},
+		},
+		{
+			lesson: "c02l03",
+			task:   "embedding",
+		},
+		{
+			lesson: "c02l05",
+			task:   "functions",
+		},
+		{
+			lesson: "c03l01",
+			task:   "rodo",
+		},
+		{
+			lesson: "c03l03",
+			task:   "whoami",
+			script: ai.Script{Chat: []ai.ScriptedReply{{Match: "Fact: stworzył", Content: "Bill Gates"}}},
+		},
+		{
+			lesson: "c04l01",
+			task:   "knowledge",
+			script: ai.Script{Chat: []ai.ScriptedReply{{Match: "stolica Czech", Content: "Praga"}}},
+		},
+		{
+			lesson: "c04l02",
+			task:   "tools",
+			script: ai.Script{Chat: []ai.ScriptedReply{{
+				Match: "kupić mleko",
+				Call:  &openai.FunctionCall{Name: "respond", Arguments: `{"tool":"ToDo","desc":"Kup mleko"}`},
+			}}},
+		},
+		{
+			lesson: "c04l03",
+			task:   "gnome",
+			script: ai.Script{Chat: []ai.ScriptedReply{{Match: "What color is the hat", Content: "czerwony"}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.lesson, func(t *testing.T) {
+			// given
+			fixture, exist := fixtures[tc.task]
+			require.True(t, exist)
+			server := &fakeTaskServer{fixture: fixture}
+			sut := CreateTaskSolver(tc.lesson, NewContainer(ai.NewScripted(tc.script), config.Default()))
+
+			// when
+			err := sut.Solve(context.Background(), server)
+
+			// then
+			assert.NoError(t, err)
+			assert.NotEmpty(t, server.solution)
+			if len(fixture.Answer) != 0 {
+				assert.JSONEq(t, string(fixture.Answer), string(server.solution))
+			}
+		})
+	}
+}
+
+func TestShouldStreamChaptersToOutputOfLesson04b(t *testing.T) {
+	// given
+	var out bytes.Buffer
+	provider := ai.NewScripted(ai.Script{Chat: []ai.ScriptedReply{{Content: "Pizza Margherita to klasyka."}}})
+	sut := Lesson04b{
+		streamer: ai.NewChat(provider),
+		out:      &out,
+	}
+
+	// when
+	solution, err := sut.getSolution(context.Background(), Lesson04bTask{Blog: []string{"Wstęp", "Pieczenie"}})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Lesson04bSolution{"Pizza Margherita to klasyka.", "Pizza Margherita to klasyka."}, solution)
+	assert.Equal(t, "Pizza Margherita to klasyka.\nPizza Margherita to klasyka.\n", out.String())
+}
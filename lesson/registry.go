@@ -1,56 +1,225 @@
 package lesson
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"unicode"
 
+	"github.com/koenno/aidevs2/config"
 	"github.com/koenno/aidevs2/task"
 )
 
-type Task struct {
-	Token string `json:"-"`
-	Code  int    `json:"code"`
-	Msg   string `json:"msg"`
-}
-
 type TaskServer interface {
-	FetchTask(name string, task task.AIDevsTask) error
-	SendSolution(token string, solution any) error
+	FetchTask(ctx context.Context, name string, task task.AIDevsTask) error
+	AskQuestion(ctx context.Context, token, question string) (string, error)
+	SendSolution(ctx context.Context, token string, solution any) (task.Verdict, error)
 }
 
 type TaskSolverFactory interface {
-	Create(openaiKey string) TaskSolver
+	Create(container *Container) TaskSolver
 }
 
 type TaskSolver interface {
-	Solve(s TaskServer) error
+	Solve(ctx context.Context, s TaskServer) error
+}
+
+// fetchTask fetches the task with its payload decoded into T
+func fetchTask[T any](ctx context.Context, server TaskServer, name string) (task.Envelope[T], error) {
+	var t task.Envelope[T]
+	if err := server.FetchTask(ctx, name, &t); err != nil {
+		return task.Envelope[T]{}, err
+	}
+	return t, nil
+}
+
+// Service is an external service a solver depends on besides AI Devs
+type Service string
+
+const (
+	ServiceOpenAI    Service = "openai"
+	ServiceVectorDB  Service = "vectordb"
+	ServiceNoSQLDB   Service = "nosqldb"
+	ServiceWeb       Service = "web"
+	ServiceKnowledge Service = "knowledge"
+	ServiceOwnAPI    Service = "ownapi"
+)
+
+// model roles resolved to model names with the config, any other model is a model name
+const (
+	ModelChat      = "chat"
+	ModelReasoning = "reasoning"
+	ModelTools     = "tools"
+)
+
+var (
+	ErrUnknownLesson   = errors.New("unknown lesson")
+	ErrAmbiguousLesson = errors.New("ambiguous lesson")
+)
+
+// Info describes a registered lesson
+type Info struct {
+	// ID is the canonical lesson id like c03l05
+	ID          string
+	Aliases     []string
+	TaskName    string
+	Description string
+	Services    []Service
+	Models      []string
+}
+
+// ModelNames resolves model roles to the configured model names
+func (i Info) ModelNames(cfg config.Config) []string {
+	names := make([]string, len(i.Models))
+	for j, m := range i.Models {
+		switch m {
+		case ModelChat:
+			names[j] = cfg.Models.Chat
+		case ModelReasoning:
+			names[j] = cfg.Models.Reasoning
+		case ModelTools:
+			names[j] = cfg.Models.Tools
+		default:
+			names[j] = m
+		}
+	}
+	return names
+}
+
+type registration struct {
+	info    Info
+	factory TaskSolverFactory
 }
 
 var (
-	registry = make(map[string]TaskSolverFactory)
+	registry = make(map[string]registration)
 )
 
+// register panics when a name of the lesson equals a name of another one, partial matches are not conflicts
+func register(info Info, factory TaskSolverFactory) {
+	r := registration{
+		info:    info,
+		factory: factory,
+	}
+	taken := make(map[string]string)
+	for _, other := range registry {
+		for _, name := range other.names() {
+			taken[normalize(name)] = other.info.ID
+		}
+	}
+	for _, name := range r.names() {
+		n := normalize(name)
+		if n == "" {
+			panic(fmt.Sprintf("lesson %s has an empty name %q", info.ID, name))
+		}
+		if id, exist := taken[n]; exist {
+			panic(fmt.Sprintf("lesson %s is already defined by %s", name, id))
+		}
+	}
+	registry[info.ID] = r
+}
+
+// Lessons returns all registered lessons ordered by id
+func Lessons() []Info {
+	lessons := make([]Info, 0, len(registry))
+	for _, r := range registry {
+		lessons = append(lessons, r.info)
+	}
+	sort.Slice(lessons, func(i, j int) bool {
+		return lessons[i].ID < lessons[j].ID
+	})
+	return lessons
+}
+
 func AllLessons() []string {
 	var lessons []string
-	for k := range registry {
-		lessons = append(lessons, k)
+	for _, info := range Lessons() {
+		lessons = append(lessons, info.ID)
 	}
 	return lessons
 }
 
-func CreateTaskSolver(lessonName, openaiKey string) TaskSolver {
-	lessonCreator, exists := registry[lessonName]
-	if !exists {
+// Find looks the lesson up by id, alias or task name, zero padding and case are ignored and a unique partial match is accepted
+func Find(query string) (Info, error) {
+	r, err := find(query)
+	if err != nil {
+		return Info{}, err
+	}
+	return r.info, nil
+}
+
+func find(query string) (registration, error) {
+	q := normalize(query)
+	if q == "" {
+		return registration{}, fmt.Errorf("%w: empty name", ErrUnknownLesson)
+	}
+	var partial []registration
+	for _, r := range registry {
+		for _, name := range r.names() {
+			if normalize(name) == q {
+				return r, nil
+			}
+		}
+		for _, name := range r.names() {
+			if strings.Contains(normalize(name), q) {
+				partial = append(partial, r)
+				break
+			}
+		}
+	}
+	switch len(partial) {
+	case 0:
+		return registration{}, fmt.Errorf("%w: %s", ErrUnknownLesson, query)
+	case 1:
+		return partial[0], nil
+	}
+	ids := make([]string, len(partial))
+	for i, r := range partial {
+		ids[i] = r.info.ID
+	}
+	sort.Strings(ids)
+	return registration{}, fmt.Errorf("%w: %s matches %s", ErrAmbiguousLesson, query, strings.Join(ids, ", "))
+}
+
+func (r registration) names() []string {
+	return append([]string{r.info.ID, r.info.TaskName}, r.info.Aliases...)
+}
+
+// normalize lowercases the name, drops separators and leading zeros of numbers so C03L05, c3l5 and c03-l05 are equal
+func normalize(name string) string {
+	var b strings.Builder
+	prev := rune(0)
+	for _, r := range strings.ToLower(name) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		if r == '0' && !unicode.IsDigit(prev) {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+func CreateTaskSolver(lessonName string, container *Container) TaskSolver {
+	r, err := find(lessonName)
+	if err != nil {
 		return UnsupportedLessonSolver{
 			name: lessonName,
+			err:  err,
 		}
 	}
-	return lessonCreator.Create(openaiKey)
+	return r.factory.Create(container)
 }
 
 type UnsupportedLessonSolver struct {
 	name string
+	err  error
 }
 
-func (s UnsupportedLessonSolver) Solve(_ TaskServer) error {
-	return fmt.Errorf("unsupported lesson solver %s", s.name)
+func (s UnsupportedLessonSolver) Solve(_ context.Context, _ TaskServer) error {
+	return fmt.Errorf("unsupported lesson solver %s: %w", s.name, s.err)
 }
@@ -0,0 +1,96 @@
+package lesson
+
+import (
+	"testing"
+
+	"github.com/koenno/aidevs2/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldFindLessonByAnyName(t *testing.T) {
+	testCases := []struct {
+		query string
+		id    string
+	}{
+		{query: "c03l05", id: "c03l05"},
+		{query: "C3L5", id: "c03l05"},
+		{query: "people", id: "c03l05"},
+		{query: "4a", id: "c01l04a"},
+		{query: "1", id: "c01l01"},
+		{query: "ownapipro", id: "c04l05"},
+		{query: "whisp", id: "c02l04"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			// when
+			info, err := Find(tc.query)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, tc.id, info.ID)
+		})
+	}
+}
+
+func TestShouldRejectUnknownAndAmbiguousLessons(t *testing.T) {
+	// when
+	_, errUnknown := Find("c09l09")
+	_, errAmbiguous := Find("c3")
+
+	// then
+	assert.ErrorIs(t, errUnknown, ErrUnknownLesson)
+	assert.ErrorIs(t, errAmbiguous, ErrAmbiguousLesson)
+	assert.ErrorContains(t, errAmbiguous, "c03l01, c03l02")
+}
+
+func TestShouldResolveModelRoles(t *testing.T) {
+	// given
+	cfg := config.Default()
+	cfg.Models.Tools = "some-model"
+	sut := Info{Models: []string{ModelTools, "whisper-1"}}
+
+	// when
+	names := sut.ModelNames(cfg)
+
+	// then
+	assert.Equal(t, []string{"some-model", "whisper-1"}, names)
+}
+
+func TestShouldListLessonsInOrder(t *testing.T) {
+	// when
+	lessons := AllLessons()
+
+	// then
+	assert.Len(t, lessons, 18)
+	assert.Equal(t, "c01l01", lessons[0])
+	assert.Equal(t, "c04l05", lessons[len(lessons)-1])
+}
+
+func TestShouldRegisterLessonNamedLikePartOfAnother(t *testing.T) {
+	// given
+	t.Cleanup(func() {
+		delete(registry, "test-abc")
+		delete(registry, "test-ab")
+	})
+	register(Info{ID: "test-abc", TaskName: "abc"}, nil)
+
+	// when
+	register(Info{ID: "test-ab", TaskName: "ab"}, nil)
+
+	// then
+	info, err := Find("ab")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-ab", info.ID)
+}
+
+func TestShouldRejectConflictingLessonNames(t *testing.T) {
+	// given
+	t.Cleanup(func() { delete(registry, "test-abc") })
+	register(Info{ID: "test-abc", TaskName: "abc"}, nil)
+
+	// then
+	assert.Panics(t, func() { register(Info{ID: "test-other", TaskName: "other", Aliases: []string{"ABC"}}, nil) })
+	assert.Panics(t, func() { register(Info{ID: "test-empty", TaskName: "empty", Aliases: []string{"--"}}, nil) })
+	assert.NotContains(t, registry, "test-other")
+	assert.NotContains(t, registry, "test-empty")
+}
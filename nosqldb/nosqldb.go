@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,6 +13,9 @@ import (
 
 const (
 	dbName = "aidevs2"
+
+	ServerSelectionTimeout = 10 * time.Second
+	OperationTimeout       = 30 * time.Second
 )
 
 type DB struct {
@@ -20,7 +24,12 @@ type DB struct {
 
 func New(addr string) (*DB, error) {
 	URL := fmt.Sprintf("mongodb://%s", addr)
-	opts := options.Client().ApplyURI(URL)
+	// the driver retries a failed read or write once on its own, the resilience policy does not cover mongo
+	opts := options.Client().ApplyURI(URL).
+		SetRetryReads(true).
+		SetRetryWrites(true).
+		SetServerSelectionTimeout(ServerSelectionTimeout).
+		SetTimeout(OperationTimeout)
 	client, err := mongo.Connect(context.Background(), opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to mongo: %v", err)
@@ -0,0 +1,183 @@
+package ownapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	AnswerPath = "/answer"
+
+	ConversationHeader  = "X-Conversation-ID"
+	defaultConversation = "default"
+
+	rules = `
+Strict rules of this conversation:
+- I answer questions ultra-concise, with a single word or a short sentence
+- I'll always skip any comments entirely
+- I'm always truthful and honestly say "I don't know" when you ask me about something beyond my current knowledge
+`
+	memoryRules = `- If you tell me something about yourself I only confirm it shortly with "OK"
+- I use the facts you told me earlier to answer your questions
+`
+)
+
+type Chat interface {
+	ModeratedChat(ctx context.Context, system string, userMsgs ...string) (string, error)
+}
+
+type Question struct {
+	Question string `json:"question"`
+}
+
+type Reply struct {
+	Reply string `json:"reply"`
+}
+
+type Handler struct {
+	chat   Chat
+	memory *Memory
+}
+
+type Option func(*Handler)
+
+// WithMemory makes the handler remember everything it was told within a conversation
+func WithMemory() Option {
+	return func(h *Handler) {
+		h.memory = NewMemory()
+	}
+}
+
+func NewHandler(chat Chat, opts ...Option) *Handler {
+	h := &Handler{
+		chat: chat,
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST method is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	var q Question
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode question: %v", err), http.StatusBadRequest)
+		return
+	}
+	if q.Question == "" {
+		http.Error(w, "empty question", http.StatusBadRequest)
+		return
+	}
+	conversationID := r.Header.Get(ConversationHeader)
+	if conversationID == "" {
+		conversationID = defaultConversation
+	}
+	reply, err := h.answer(r.Context(), conversationID, q.Question)
+	if err != nil {
+		log.Printf("failed to answer question '%s': %v", q.Question, err)
+		http.Error(w, "failed to answer question", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Question: %s", q.Question)
+	log.Printf("Reply: %s", reply)
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(Reply{Reply: reply}); err != nil {
+		log.Printf("failed to encode reply: %v", err)
+	}
+}
+
+func (h *Handler) answer(ctx context.Context, conversationID, question string) (string, error) {
+	system := rules
+	if h.memory != nil {
+		system += memoryRules
+		facts := h.memory.Facts(conversationID)
+		if len(facts) != 0 {
+			system += fmt.Sprintf("\nFacts:\n%s", strings.Join(facts, "\n"))
+		}
+	}
+	reply, err := h.chat.ModeratedChat(ctx, system, question)
+	if err != nil {
+		return "", fmt.Errorf("chat failure: %v", err)
+	}
+	if h.memory != nil {
+		h.memory.Remember(conversationID, question)
+	}
+	return reply, nil
+}
+
+// Memory keeps everything the user said, separately for each conversation
+type Memory struct {
+	mu            sync.Mutex
+	conversations map[string][]string
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		conversations: make(map[string][]string),
+	}
+}
+
+func (m *Memory) Remember(conversationID, entry string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversations[conversationID] = append(m.conversations[conversationID], entry)
+}
+
+func (m *Memory) Facts(conversationID string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	facts := m.conversations[conversationID]
+	return append([]string(nil), facts...)
+}
+
+type Server struct {
+	srv      *http.Server
+	listener net.Listener
+}
+
+func Listen(addr string, handler http.Handler) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(AnswerPath, handler)
+	return &Server{
+		srv: &http.Server{
+			Handler: mux,
+		},
+		listener: listener,
+	}, nil
+}
+
+// Serve blocks until the server is closed
+func (s *Server) Serve() error {
+	err := s.srv.Serve(s.listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %v", err)
+	}
+	return nil
+}
+
+// URL returns the local address of the answer endpoint
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://%s%s", s.listener.Addr(), AnswerPath)
+}
+
+func (s *Server) Close(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown server: %v", err)
+	}
+	return nil
+}
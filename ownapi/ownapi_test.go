@@ -0,0 +1,85 @@
+package ownapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChat struct {
+	systems []string
+	reply   string
+}
+
+func (c *fakeChat) ModeratedChat(ctx context.Context, system string, userMsgs ...string) (string, error) {
+	c.systems = append(c.systems, system)
+	return c.reply, nil
+}
+
+func ask(handler http.Handler, question string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(Question{Question: question})
+	req := httptest.NewRequest(http.MethodPost, AnswerPath, strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestShouldReplyToQuestion(t *testing.T) {
+	// given
+	chat := &fakeChat{reply: "Warsaw"}
+	sut := NewHandler(chat)
+
+	// when
+	rec := ask(sut, "What is the capital of Poland?")
+
+	// then
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var reply Reply
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&reply))
+	assert.Equal(t, "Warsaw", reply.Reply)
+}
+
+func TestShouldRejectEmptyQuestion(t *testing.T) {
+	// given
+	sut := NewHandler(&fakeChat{})
+
+	// when
+	rec := ask(sut, "")
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestShouldUseFactsFromEarlierQuestionsWhenMemoryIsEnabled(t *testing.T) {
+	// given
+	chat := &fakeChat{reply: "OK"}
+	sut := NewHandler(chat, WithMemory())
+
+	// when
+	ask(sut, "I live in Cracow")
+	ask(sut, "Where do I live?")
+
+	// then
+	assert.Len(t, chat.systems, 2)
+	assert.NotContains(t, chat.systems[0], "I live in Cracow")
+	assert.Contains(t, chat.systems[1], "I live in Cracow")
+}
+
+func TestShouldNotRememberFactsWithoutMemory(t *testing.T) {
+	// given
+	chat := &fakeChat{reply: "OK"}
+	sut := NewHandler(chat)
+
+	// when
+	ask(sut, "I live in Cracow")
+	ask(sut, "Where do I live?")
+
+	// then
+	assert.Len(t, chat.systems, 2)
+	assert.NotContains(t, chat.systems[1], "I live in Cracow")
+}
@@ -0,0 +1,388 @@
+// Package recording records HTTP requests and unary gRPC calls into cassettes and replays them offline.
+// MongoDB is not covered, its driver talks its own wire protocol with no hook to record it, so lessons
+// requiring the NoSQL database, e.g. c03l05, still need a running MongoDB when replayed.
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	KindHTTP = "http"
+	KindGRPC = "grpc"
+
+	redacted = "REDACTED"
+	boundary = "BOUNDARY"
+)
+
+var (
+	ErrNotRecorded = errors.New("interaction not recorded")
+)
+
+// Interaction is a single request with its response, cassettes keep one interaction per line
+type Interaction struct {
+	Kind string `json:"kind"`
+	// Method is the HTTP method or the full gRPC method name
+	Method string `json:"method"`
+	URL    string `json:"url,omitempty"`
+	// RequestHash identifies the request body, requests of the same method, URL and body are replayed in the recorded order
+	RequestHash string `json:"requestHash,omitempty"`
+	Request     string `json:"request,omitempty"`
+	// Status is the HTTP status or the gRPC code
+	Status         int         `json:"status,omitempty"`
+	Header         http.Header `json:"header,omitempty"`
+	Response       string      `json:"response,omitempty"`
+	ResponseBase64 bool        `json:"responseBase64,omitempty"`
+	Error          string      `json:"error,omitempty"`
+}
+
+// Cassette records interactions into a JSONL file or replays them from it
+type Cassette struct {
+	mu      sync.Mutex
+	secrets []string
+	file    *os.File
+	enc     *json.Encoder
+	replay  map[string][]Interaction
+}
+
+type Option func(*Cassette)
+
+// WithRedact replaces secrets, e.g. API keys, in recorded URLs and request bodies, replayed requests are redacted the same way before matching
+func WithRedact(secrets ...string) Option {
+	return func(c *Cassette) {
+		for _, s := range secrets {
+			if s != "" {
+				c.secrets = append(c.secrets, s)
+			}
+		}
+	}
+}
+
+// Record creates the cassette file, every interaction is appended to it as it completes
+func Record(path string, opts ...Option) (*Cassette, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette %s: %v", path, err)
+	}
+	c := &Cassette{
+		file: f,
+		enc:  json.NewEncoder(f),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c, nil
+}
+
+// Replay loads the cassette file, requests are answered from it without reaching the network
+func Replay(path string, opts ...Option) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette %s: %v", path, err)
+	}
+	defer f.Close()
+	c := &Cassette{
+		replay: make(map[string][]Interaction),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("failed to decode interaction in line %d of %s: %v", line, path, err)
+		}
+		k := i.key()
+		c.replay[k] = append(c.replay[k], i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Replaying tells whether requests are answered from the cassette
+func (c *Cassette) Replaying() bool {
+	return c.replay != nil
+}
+
+// Unused returns the number of recorded interactions which have not been replayed
+func (c *Cassette) Unused() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, ii := range c.replay {
+		n += len(ii)
+	}
+	return n
+}
+
+func (c *Cassette) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	if err := c.file.Close(); err != nil {
+		return fmt.Errorf("failed to close cassette: %v", err)
+	}
+	return nil
+}
+
+func (c *Cassette) save(i Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.enc.Encode(i); err != nil {
+		return fmt.Errorf("failed to record interaction: %v", err)
+	}
+	return nil
+}
+
+func (c *Cassette) next(key, describe string) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ii := c.replay[key]
+	if len(ii) == 0 {
+		return Interaction{}, fmt.Errorf("%w: %s", ErrNotRecorded, describe)
+	}
+	c.replay[key] = ii[1:]
+	return ii[0], nil
+}
+
+func (c *Cassette) redact(s string) string {
+	for _, secret := range c.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+func (i Interaction) key() string {
+	return i.Kind + " " + i.Method + " " + i.URL + " " + i.RequestHash
+}
+
+func hash(body string) string {
+	if body == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+var active atomic.Pointer[Cassette]
+
+// Use makes the cassette serve transports and interceptors of this package, the returned function restores the previous one
+func Use(c *Cassette) (restore func()) {
+	prev := active.Swap(c)
+	return func() {
+		active.Store(prev)
+	}
+}
+
+// Transport wraps next with recording or replaying done by the cassette in use, requests pass through when there is none
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := active.Load()
+	if c == nil {
+		return t.next.RoundTrip(req)
+	}
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	request := c.redact(normalizeBoundary(req.Header.Get("Content-Type"), body))
+	i := Interaction{
+		Kind:        KindHTTP,
+		Method:      req.Method,
+		URL:         c.redact(req.URL.String()),
+		RequestHash: hash(request),
+	}
+
+	if c.Replaying() {
+		return c.replayHTTP(req, i)
+	}
+
+	if utf8.ValidString(request) {
+		i.Request = request
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		i.Error = err.Error()
+		if saveErr := c.save(i); saveErr != nil {
+			return nil, saveErr
+		}
+		return nil, err
+	}
+	// the whole body is read so streamed responses are delivered at once while recording
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %s %s: %v", req.Method, i.URL, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	i.Status = resp.StatusCode
+	i.Header = resp.Header.Clone()
+	i.Header.Del("Set-Cookie")
+	i.Response, i.ResponseBase64 = encodeBody(respBody)
+	if err := c.save(i); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Cassette) replayHTTP(req *http.Request, match Interaction) (*http.Response, error) {
+	i, err := c.next(match.key(), req.Method+" "+match.URL)
+	if err != nil {
+		return nil, err
+	}
+	if i.Error != "" {
+		return nil, errors.New(i.Error)
+	}
+	respBody := []byte(i.Response)
+	if i.ResponseBase64 {
+		if respBody, err = base64.StdEncoding.DecodeString(i.Response); err != nil {
+			return nil, fmt.Errorf("failed to decode recorded response of %s %s: %v", req.Method, match.URL, err)
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("failed to get request body: %v", err)
+		}
+		defer body.Close()
+		bb, err := io.ReadAll(body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read request body: %v", err)
+		}
+		return string(bb), nil
+	}
+	bb, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %v", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(bb))
+	return string(bb), nil
+}
+
+// normalizeBoundary replaces the random multipart boundary so uploads of the same file are equal
+func normalizeBoundary(contentType, body string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return body
+	}
+	return strings.ReplaceAll(body, params["boundary"], boundary)
+}
+
+func encodeBody(bb []byte) (string, bool) {
+	if utf8.Valid(bb) {
+		return string(bb), false
+	}
+	return base64.StdEncoding.EncodeToString(bb), true
+}
+
+// UnaryInterceptor records or replays unary gRPC calls with the cassette in use, calls pass through when there is none
+func UnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		c := active.Load()
+		reqMsg, reqOK := req.(proto.Message)
+		replyMsg, replyOK := reply.(proto.Message)
+		if c == nil || !reqOK || !replyOK {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		bb, err := proto.MarshalOptions{Deterministic: true}.Marshal(reqMsg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request of %s: %v", method, err)
+		}
+		i := Interaction{
+			Kind:        KindGRPC,
+			Method:      method,
+			RequestHash: hash(c.redact(string(bb))),
+		}
+
+		if c.Replaying() {
+			i, err := c.next(i.key(), method)
+			if err != nil {
+				return err
+			}
+			if codes.Code(i.Status) != codes.OK {
+				return status.Error(codes.Code(i.Status), i.Error)
+			}
+			if err := protojson.Unmarshal([]byte(i.Response), replyMsg); err != nil {
+				return fmt.Errorf("failed to decode recorded reply of %s: %v", method, err)
+			}
+			return nil
+		}
+
+		if request, err := protojson.Marshal(reqMsg); err == nil {
+			i.Request = c.redact(string(request))
+		}
+		callErr := invoker(ctx, method, req, reply, cc, opts...)
+		if callErr != nil {
+			st := status.Convert(callErr)
+			i.Status = int(st.Code())
+			i.Error = st.Message()
+		} else {
+			response, err := protojson.Marshal(replyMsg)
+			if err != nil {
+				return fmt.Errorf("failed to encode reply of %s: %v", method, err)
+			}
+			i.Response = string(response)
+		}
+		if err := c.save(i); err != nil {
+			return err
+		}
+		return callErr
+	}
+}
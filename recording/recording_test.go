@@ -0,0 +1,192 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func post(t *testing.T, client *http.Client, url, body string) (int, string) {
+	t.Helper()
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	bb, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(bb)
+}
+
+func TestShouldReplayRecordedInteractionsOffline(t *testing.T) {
+	// given
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		bb, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%d: %s", n, bb)
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	client := &http.Client{Transport: Transport(nil)}
+
+	recorder, err := Record(path, WithRedact("secret"))
+	require.NoError(t, err)
+	restore := Use(recorder)
+	post(t, client, srv.URL+"/a", `{"key":"secret"}`)
+	post(t, client, srv.URL+"/a", `{"key":"secret"}`)
+	post(t, client, srv.URL+"/b", `{}`)
+	restore()
+	require.NoError(t, recorder.Close())
+	srv.Close()
+
+	// when
+	player, err := Replay(path, WithRedact("other"))
+	require.NoError(t, err)
+	defer Use(player)()
+	status1, body1 := post(t, client, srv.URL+"/a", `{"key":"other"}`)
+	status2, body2 := post(t, client, srv.URL+"/a", `{"key":"other"}`)
+	status3, body3 := post(t, client, srv.URL+"/b", `{}`)
+	_, errMissing := client.Post(srv.URL+"/b", "application/json", strings.NewReader(`{}`))
+
+	// then
+	assert.Equal(t, http.StatusCreated, status1)
+	assert.Equal(t, `1: {"key":"secret"}`, body1)
+	assert.Equal(t, http.StatusCreated, status2)
+	assert.Equal(t, `2: {"key":"secret"}`, body2)
+	assert.Equal(t, http.StatusCreated, status3)
+	assert.Equal(t, `3: {}`, body3)
+	assert.ErrorIs(t, errMissing, ErrNotRecorded)
+	assert.Zero(t, player.Unused())
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestShouldNotKeepSecretsInCassette(t *testing.T) {
+	// given
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	recorder, err := Record(path, WithRedact("secret"))
+	require.NoError(t, err)
+	client := &http.Client{Transport: Transport(nil)}
+
+	// when
+	restore := Use(recorder)
+	post(t, client, srv.URL+"/secret", `{"apikey":"secret"}`)
+	restore()
+	require.NoError(t, recorder.Close())
+
+	// then
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(bb), "secret")
+	assert.Contains(t, string(bb), "/REDACTED")
+}
+
+func TestShouldMatchMultipartUploadsRegardlessOfBoundary(t *testing.T) {
+	// given
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0xff, 0x00, 0xfe})
+	}))
+	upload := func(client *http.Client) []byte {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("file", "audio.mp3")
+		require.NoError(t, err)
+		part.Write([]byte("audio"))
+		require.NoError(t, mw.Close())
+		resp, err := client.Post(srv.URL, mw.FormDataContentType(), &body)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		bb, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return bb
+	}
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	client := &http.Client{Transport: Transport(nil)}
+	recorder, err := Record(path)
+	require.NoError(t, err)
+	restore := Use(recorder)
+	upload(client)
+	restore()
+	require.NoError(t, recorder.Close())
+	srv.Close()
+
+	// when
+	player, err := Replay(path)
+	require.NoError(t, err)
+	defer Use(player)()
+	response := upload(client)
+
+	// then
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, response)
+}
+
+func TestShouldPassRequestsThroughWithoutCassette(t *testing.T) {
+	// given
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("live"))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	// when
+	_, body := post(t, client, srv.URL, `{}`)
+
+	// then
+	assert.Equal(t, "live", body)
+}
+
+func TestShouldReplayRecordedGRPCCalls(t *testing.T) {
+	// given
+	interceptor := UnaryInterceptor()
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		value := req.(*wrapperspb.StringValue).Value
+		if value == "missing" {
+			return status.Error(codes.NotFound, "no such collection")
+		}
+		reply.(*wrapperspb.StringValue).Value = "reply to " + value
+		return nil
+	}
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	recorder, err := Record(path)
+	require.NoError(t, err)
+	restore := Use(recorder)
+	require.NoError(t, interceptor(context.Background(), "/svc/Get", wrapperspb.String("hello"), &wrapperspb.StringValue{}, nil, invoker))
+	require.Error(t, interceptor(context.Background(), "/svc/Get", wrapperspb.String("missing"), &wrapperspb.StringValue{}, nil, invoker))
+	restore()
+	require.NoError(t, recorder.Close())
+
+	// when
+	player, err := Replay(path)
+	require.NoError(t, err)
+	defer Use(player)()
+	noInvoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		t.Fatal("call should be replayed")
+		return nil
+	}
+	reply := &wrapperspb.StringValue{}
+	errHello := interceptor(context.Background(), "/svc/Get", wrapperspb.String("hello"), reply, nil, noInvoker)
+	errMissing := interceptor(context.Background(), "/svc/Get", wrapperspb.String("missing"), &wrapperspb.StringValue{}, nil, noInvoker)
+	errOther := interceptor(context.Background(), "/svc/Get", wrapperspb.String("other"), &wrapperspb.StringValue{}, nil, noInvoker)
+
+	// then
+	assert.NoError(t, errHello)
+	assert.Equal(t, "reply to hello", reply.Value)
+	assert.Equal(t, codes.NotFound, status.Code(errMissing))
+	assert.ErrorIs(t, errOther, ErrNotRecorded)
+}
@@ -2,25 +2,36 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 const (
-	endpoint = "https://zadania.aidevs.pl"
+	DefaultEndpoint = "https://zadania.aidevs.pl"
 )
 
 type Factory struct {
+	// Endpoint is the base URL of the AI Devs API, DefaultEndpoint is used when empty
+	Endpoint string
+}
+
+func (f Factory) endpoint() string {
+	if f.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return strings.TrimSuffix(f.Endpoint, "/")
 }
 
 type AuthenticateRequest struct {
 	ApiKey string `json:"apikey"`
 }
 
-func (f Factory) Authenticate(apiKey, taskName string) (*http.Request, error) {
-	rawURL := fmt.Sprintf("%s/token/%s", endpoint, taskName)
+func (f Factory) Authenticate(ctx context.Context, apiKey, taskName string) (*http.Request, error) {
+	rawURL := fmt.Sprintf("%s/token/%s", f.endpoint(), taskName)
 	URL, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse an authentication url: %v", err)
@@ -32,20 +43,20 @@ func (f Factory) Authenticate(apiKey, taskName string) (*http.Request, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode authentication payload: %v", err)
 	}
-	req, err := http.NewRequest(http.MethodPost, URL.String(), bytes.NewReader(bb))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewReader(bb))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create an authentication request: %v", err)
 	}
 	return req, nil
 }
 
-func (f Factory) Task(token string) (*http.Request, error) {
-	rawURL := fmt.Sprintf("%s/task/%s", endpoint, token)
+func (f Factory) Task(ctx context.Context, token string) (*http.Request, error) {
+	rawURL := fmt.Sprintf("%s/task/%s", f.endpoint(), token)
 	URL, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse a task url: %v", err)
 	}
-	req, err := http.NewRequest(http.MethodGet, URL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a task request: %v", err)
 	}
@@ -56,8 +67,8 @@ type AnswerRequest struct {
 	Answer any `json:"answer"`
 }
 
-func (f Factory) Answer(token string, answerData any) (*http.Request, error) {
-	rawURL := fmt.Sprintf("%s/answer/%s", endpoint, token)
+func (f Factory) Answer(ctx context.Context, token string, answerData any) (*http.Request, error) {
+	rawURL := fmt.Sprintf("%s/answer/%s", f.endpoint(), token)
 	URL, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse a task url: %v", err)
@@ -69,9 +80,26 @@ func (f Factory) Answer(token string, answerData any) (*http.Request, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode task payload: %v", err)
 	}
-	req, err := http.NewRequest(http.MethodPost, URL.String(), bytes.NewReader(bb))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewReader(bb))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a task request: %v", err)
 	}
 	return req, nil
 }
+
+func (f Factory) Question(ctx context.Context, token, question string) (*http.Request, error) {
+	rawURL := fmt.Sprintf("%s/task/%s", f.endpoint(), token)
+	URL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse a question url: %v", err)
+	}
+	data := url.Values{
+		"question": {question},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a question request: %v", err)
+	}
+	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+	return req, nil
+}
@@ -0,0 +1,55 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/eapache/go-resiliency/breaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor retries transient failures of gRPC calls and fails fast once the remote keeps failing,
+// it follows the same options as Transport
+func UnaryInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	policy := NewTransport(nil, opts...)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if policy.breaker == nil {
+			return policy.invoke(ctx, method, req, reply, cc, invoker, callOpts...)
+		}
+		var callErr error
+		err := policy.breaker.Run(func() error {
+			callErr = policy.invoke(ctx, method, req, reply, cc, invoker, callOpts...)
+			if callErr != nil && ctx.Err() == nil && RetryableCode(status.Code(callErr)) {
+				return callErr
+			}
+			return nil
+		})
+		if errors.Is(err, breaker.ErrBreakerOpen) {
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, method)
+		}
+		return callErr
+	}
+}
+
+func (t *Transport) invoke(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+	for attempt := 0; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if err == nil || attempt >= t.retries || ctx.Err() != nil || !RetryableCode(status.Code(err)) {
+			return err
+		}
+		delay := t.delay(attempt, nil)
+		log.Printf("retrying %s in %v: %v", method, delay, err)
+		if err := t.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// RetryableCode tells whether the gRPC status is transient
+func RetryableCode(code codes.Code) bool {
+	return code == codes.Unavailable || code == codes.ResourceExhausted || code == codes.Aborted
+}
@@ -0,0 +1,69 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invoker fails with the given codes one by one, the last one repeats
+func invoker(calls *int, codes ...codes.Code) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		code := codes[min(*calls, len(codes)-1)]
+		*calls++
+		return status.Error(code, "some failure")
+	}
+}
+
+func TestShouldRetryTransientGRPCFailures(t *testing.T) {
+	// given
+	var slept sleeps
+	var calls int
+	sut := UnaryInterceptor(WithJitter(0), WithBackoff(time.Second, time.Minute), withSleep(slept.sleep))
+
+	// when
+	err := sut(context.Background(), "/qdrant.Points/Search", nil, nil, nil, invoker(&calls, codes.Unavailable, codes.ResourceExhausted, codes.OK))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, sleeps{time.Second, 2 * time.Second}, slept)
+}
+
+func TestShouldNotRetryPermanentGRPCFailures(t *testing.T) {
+	// given
+	var slept sleeps
+	var calls int
+	sut := UnaryInterceptor(withSleep(slept.sleep))
+
+	// when
+	err := sut(context.Background(), "/qdrant.Points/Search", nil, nil, nil, invoker(&calls, codes.NotFound))
+
+	// then
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, slept)
+}
+
+func TestShouldFailFastWhenGRPCCircuitIsOpen(t *testing.T) {
+	// given
+	var slept sleeps
+	var calls int
+	sut := UnaryInterceptor(WithRetries(0), WithBreaker(2, time.Minute), withSleep(slept.sleep))
+	for i := 0; i < 2; i++ {
+		err := sut(context.Background(), "/qdrant.Points/Search", nil, nil, nil, invoker(&calls, codes.Unavailable))
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+
+	// when
+	err := sut(context.Background(), "/qdrant.Points/Search", nil, nil, nil, invoker(&calls, codes.Unavailable))
+
+	// then
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,241 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eapache/go-resiliency/breaker"
+	"github.com/koenno/aidevs2/recording"
+)
+
+const (
+	DefaultRetries       = 3
+	DefaultBaseDelay     = 500 * time.Millisecond
+	DefaultMaxDelay      = 10 * time.Second
+	DefaultJitter        = 0.25
+	DefaultBreakerErrors = 5
+	DefaultBreakerWait   = 30 * time.Second
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// Transport is an http.RoundTripper retrying transient failures with backoff and failing fast once the remote keeps failing
+type Transport struct {
+	next      http.RoundTripper
+	retries   int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	jitter    float64
+	breaker   *breaker.Breaker
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+type Option func(*Transport)
+
+// WithRetries sets how many times a failed request is repeated, 0 disables retrying
+func WithRetries(n int) Option {
+	return func(t *Transport) {
+		t.retries = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, the delay doubles with every retry up to max
+func WithBackoff(base, max time.Duration) Option {
+	return func(t *Transport) {
+		t.baseDelay = base
+		t.maxDelay = max
+	}
+}
+
+// WithJitter randomizes every delay by the given fraction, e.g. 0.25 gives +/-25%
+func WithJitter(jitter float64) Option {
+	return func(t *Transport) {
+		t.jitter = jitter
+	}
+}
+
+// WithBreaker opens the circuit once errors failed requests come less than wait apart from each other, successful requests
+// in between do not reset the count. The circuit stays open for wait and 0 errors disables the breaker.
+func WithBreaker(errors int, wait time.Duration) Option {
+	return func(t *Transport) {
+		if errors <= 0 {
+			t.breaker = nil
+			return
+		}
+		t.breaker = breaker.New(errors, 1, wait)
+	}
+}
+
+func withSleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(t *Transport) {
+		t.sleep = sleep
+	}
+}
+
+// NewTransport wraps next, http.DefaultTransport is used when next is nil
+func NewTransport(next http.RoundTripper, opts ...Option) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &Transport{
+		next:      next,
+		retries:   DefaultRetries,
+		baseDelay: DefaultBaseDelay,
+		maxDelay:  DefaultMaxDelay,
+		jitter:    DefaultJitter,
+		breaker:   breaker.New(DefaultBreakerErrors, 1, DefaultBreakerWait),
+		sleep:     sleep,
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	return t
+}
+
+// NewClient creates an http.Client using the resilient transport, timeout limits all attempts together, requests may be recorded or replayed by the cassette in use
+func NewClient(timeout time.Duration, opts ...Option) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: recording.Transport(NewTransport(nil, opts...)),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.breaker == nil {
+		return t.roundTrip(req)
+	}
+	var resp *http.Response
+	var rtErr error
+	err := t.breaker.Run(func() error {
+		resp, rtErr = t.roundTrip(req)
+		if rtErr != nil && req.Context().Err() == nil {
+			return rtErr
+		}
+		if resp != nil && RetryableStatus(resp.StatusCode) {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	})
+	if errors.Is(err, breaker.ErrBreakerOpen) {
+		return nil, fmt.Errorf("%w: %s %s", ErrCircuitOpen, req.Method, req.URL.Host)
+	}
+	return resp, rtErr
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	retries := t.retries
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// the body cannot be sent again
+		retries = 0
+	}
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= retries || !Retryable(ctx, resp, err) {
+			return resp, err
+		}
+		delay := t.delay(attempt, resp)
+		if err != nil {
+			log.Printf("retrying %s %s in %v: %v", req.Method, req.URL, delay, err)
+		} else {
+			log.Printf("retrying %s %s in %v: status %d", req.Method, req.URL, delay, resp.StatusCode)
+			discard(resp)
+		}
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Retryable tells whether the outcome of a request is transient
+func Retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// timeouts, resets and refused connections
+		return true
+	}
+	return RetryableStatus(resp.StatusCode)
+}
+
+// RetryableStatus tells whether the server asked to try again later
+func RetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// delay grows exponentially, the server may ask for a longer one with Retry-After
+func (t *Transport) delay(attempt int, resp *http.Response) time.Duration {
+	d := t.baseDelay << attempt
+	if d > t.maxDelay || d <= 0 {
+		d = t.maxDelay
+	}
+	if t.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * t.jitter * float64(d))
+	}
+	if resp != nil {
+		if after, ok := RetryAfter(resp.Header, time.Now()); ok && after > d {
+			d = after
+		}
+	}
+	return d
+}
+
+// RetryAfter decodes the Retry-After header given in seconds or as an HTTP date
+func RetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %v", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("failed to close response body: %v", err)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sleeps []time.Duration
+
+func (s *sleeps) sleep(_ context.Context, d time.Duration) error {
+	*s = append(*s, d)
+	return nil
+}
+
+func newServer(t *testing.T, statuses ...int) (*httptest.Server, *[]string) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bb, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(bb))
+		status := statuses[min(len(bodies), len(statuses))-1]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "7")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestShouldRetryTransientFailuresWithBackoff(t *testing.T) {
+	// given
+	srv, bodies := newServer(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	var slept sleeps
+	sut := &http.Client{Transport: NewTransport(nil, WithJitter(0), WithBackoff(time.Second, time.Minute), withSleep(slept.sleep))}
+
+	// when
+	resp, err := sut.Post(srv.URL, "text/plain", strings.NewReader("some body"))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"some body", "some body", "some body"}, *bodies)
+	assert.Equal(t, sleeps{time.Second, 2 * time.Second}, slept)
+}
+
+func TestShouldHonorRetryAfter(t *testing.T) {
+	// given
+	srv, bodies := newServer(t, http.StatusTooManyRequests, http.StatusOK)
+	var slept sleeps
+	sut := &http.Client{Transport: NewTransport(nil, WithJitter(0), withSleep(slept.sleep))}
+
+	// when
+	resp, err := sut.Get(srv.URL)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, *bodies, 2)
+	assert.Equal(t, sleeps{7 * time.Second}, slept)
+}
+
+func TestShouldNotRetryClientErrors(t *testing.T) {
+	// given
+	srv, bodies := newServer(t, http.StatusBadRequest, http.StatusOK)
+	var slept sleeps
+	sut := &http.Client{Transport: NewTransport(nil, withSleep(slept.sleep))}
+
+	// when
+	resp, err := sut.Get(srv.URL)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Len(t, *bodies, 1)
+	assert.Empty(t, slept)
+}
+
+func TestShouldReturnLastFailureWhenRetriesRunOut(t *testing.T) {
+	// given
+	srv, bodies := newServer(t, http.StatusInternalServerError)
+	var slept sleeps
+	sut := &http.Client{Transport: NewTransport(nil, WithRetries(2), withSleep(slept.sleep))}
+
+	// when
+	resp, err := sut.Get(srv.URL)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Len(t, *bodies, 3)
+}
+
+func TestShouldFailFastWhenCircuitIsOpen(t *testing.T) {
+	// given
+	srv, bodies := newServer(t, http.StatusInternalServerError)
+	var slept sleeps
+	sut := &http.Client{Transport: NewTransport(nil, WithRetries(0), WithBreaker(2, time.Minute), withSleep(slept.sleep))}
+	for i := 0; i < 2; i++ {
+		resp, err := sut.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// when
+	_, err := sut.Get(srv.URL)
+
+	// then
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Len(t, *bodies, 2)
+}
+
+func TestShouldParseRetryAfter(t *testing.T) {
+	// given
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// when
+	seconds, ok1 := RetryAfter(http.Header{"Retry-After": {"3"}}, now)
+	date, ok2 := RetryAfter(http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, now)
+	_, ok3 := RetryAfter(http.Header{}, now)
+
+	// then
+	assert.True(t, ok1)
+	assert.Equal(t, 3*time.Second, seconds)
+	assert.True(t, ok2)
+	assert.Equal(t, time.Minute, date)
+	assert.False(t, ok3)
+}
@@ -0,0 +1,147 @@
+package retrieval
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	DefaultK1 = 1.2
+	DefaultB  = 0.75
+)
+
+// Hit is a document found by a search, a higher score means a better match
+type Hit struct {
+	Key   string
+	Score float64
+}
+
+// Index is an in-memory BM25 keyword index of documents identified by keys
+type Index struct {
+	mu       sync.RWMutex
+	k1       float64
+	b        float64
+	postings map[string]map[string]int
+	lengths  map[string]int
+	total    int
+}
+
+type IndexOption func(*Index)
+
+// WithK1 sets how quickly repeated terms stop raising the score
+func WithK1(k1 float64) IndexOption {
+	return func(i *Index) {
+		i.k1 = k1
+	}
+}
+
+// WithB sets how much long documents are penalized, 0 disables length normalization
+func WithB(b float64) IndexOption {
+	return func(i *Index) {
+		i.b = b
+	}
+}
+
+func NewIndex(opts ...IndexOption) *Index {
+	i := &Index{
+		k1:       DefaultK1,
+		b:        DefaultB,
+		postings: make(map[string]map[string]int),
+		lengths:  make(map[string]int),
+	}
+	for _, o := range opts {
+		o(i)
+	}
+	return i
+}
+
+// Len returns the number of indexed documents
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.lengths)
+}
+
+// Add indexes the text under the key, a document of the same key is replaced
+func (i *Index) Add(key, text string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(key)
+	terms := Tokenize(text)
+	for _, term := range terms {
+		docs, exist := i.postings[term]
+		if !exist {
+			docs = make(map[string]int)
+			i.postings[term] = docs
+		}
+		docs[key]++
+	}
+	i.lengths[key] = len(terms)
+	i.total += len(terms)
+}
+
+func (i *Index) remove(key string) {
+	length, exist := i.lengths[key]
+	if !exist {
+		return
+	}
+	for term, docs := range i.postings {
+		delete(docs, key)
+		if len(docs) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	delete(i.lengths, key)
+	i.total -= length
+}
+
+// Search returns at most limit documents containing any term of the query, the best match first
+func (i *Index) Search(query string, limit int) []Hit {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if len(i.lengths) == 0 || limit <= 0 {
+		return nil
+	}
+	n := float64(len(i.lengths))
+	avgLength := float64(i.total) / n
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, term := range Tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		docs := i.postings[term]
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key, tf := range docs {
+			norm := 1 - i.b
+			if avgLength > 0 {
+				norm += i.b * float64(i.lengths[key]) / avgLength
+			}
+			f := float64(tf)
+			scores[key] += idf * f * (i.k1 + 1) / (f + i.k1*norm)
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, Hit{Key: key, Score: score})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Key < hits[b].Key
+	})
+	return hits[:min(limit, len(hits))]
+}
+
+// Tokenize splits the text into lower case words and numbers, everything else separates them
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,85 @@
+package retrieval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keys(hits []Hit) []string {
+	result := make([]string, len(hits))
+	for i, h := range hits {
+		result[i] = h.Key
+	}
+	return result
+}
+
+func TestShouldTokenizeWordsAndNumbers(t *testing.T) {
+	// when
+	tokens := Tokenize("Ustawa RODO, z 25.05.2018 r. — Łódź!")
+
+	// then
+	assert.Equal(t, []string{"ustawa", "rodo", "z", "25", "05", "2018", "r", "łódź"}, tokens)
+}
+
+func TestShouldRankDocumentsByBM25(t *testing.T) {
+	// given
+	sut := NewIndex()
+	sut.Add("rodo", "Ustawa o ochronie danych osobowych RODO")
+	sut.Add("pizza", "Przepis na pizzę neapolitańską")
+	sut.Add("dane", "Dane dane dane, czyli jak analizować dane osobowe w firmie i w domu")
+
+	// when
+	hits := sut.Search("ustawa rodo dane", 10)
+
+	// then
+	assert.Equal(t, []string{"rodo", "dane"}, keys(hits))
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+}
+
+func TestShouldFavourRareTerms(t *testing.T) {
+	// given
+	sut := NewIndex()
+	sut.Add("a", "kot pies")
+	sut.Add("b", "kot chomik")
+	sut.Add("c", "kot rybka")
+
+	// when
+	hits := sut.Search("kot chomik", 10)
+
+	// then
+	require.Len(t, hits, 3)
+	assert.Equal(t, "b", hits[0].Key)
+	assert.Equal(t, hits[1].Score, hits[2].Score, "the common term scores the same everywhere")
+}
+
+func TestShouldReplaceDocumentOfTheSameKey(t *testing.T) {
+	// given
+	sut := NewIndex()
+	sut.Add("a", "stary tytuł")
+	sut.Add("b", "inny tytuł")
+
+	// when
+	sut.Add("a", "nowy nagłówek")
+
+	// then
+	assert.Equal(t, 2, sut.Len())
+	assert.Empty(t, sut.Search("stary", 10))
+	assert.Equal(t, []string{"a"}, keys(sut.Search("nowy", 10)))
+	assert.Equal(t, []string{"b"}, keys(sut.Search("tytuł", 10)))
+}
+
+func TestShouldLimitHits(t *testing.T) {
+	// given
+	sut := NewIndex()
+	sut.Add("a", "kot")
+	sut.Add("b", "kot kot")
+	sut.Add("c", "kot kot kot")
+
+	// when
+	hits := sut.Search("kot", 2)
+
+	// then
+	assert.Equal(t, []string{"c", "b"}, keys(hits))
+}
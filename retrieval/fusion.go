@@ -0,0 +1,27 @@
+package retrieval
+
+import "sort"
+
+// DefaultRRFConstant dampens the advantage of top ranks as suggested by the original reciprocal rank fusion paper
+const DefaultRRFConstant = 60
+
+// Fuse merges rankings of keys with reciprocal rank fusion, a key scores 1/(k+rank) in every ranking it appears in
+func Fuse(k int, rankings ...[]string) []Hit {
+	scores := make(map[string]float64)
+	for _, ranking := range rankings {
+		for rank, key := range ranking {
+			scores[key] += 1 / float64(k+rank+1)
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, Hit{Key: key, Score: score})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Key < hits[b].Key
+	})
+	return hits
+}
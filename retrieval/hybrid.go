@@ -0,0 +1,266 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/koenno/aidevs2/vectordb"
+	qdrant "github.com/qdrant/go-client/qdrant"
+)
+
+const (
+	DefaultCandidates = 20
+
+	idField = "_id"
+)
+
+type Searcher interface {
+	Search(ctx context.Context, collectionName string, vector []float32, items any, options ...vectordb.SearchOption) error
+}
+
+type Embeddor interface {
+	ModeratedEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+type Judge interface {
+	Rerank(ctx context.Context, question string, passages []string) ([]int, error)
+}
+
+// Result is an item found by Hybrid, the score is its reciprocal rank fusion score
+type Result[T any] struct {
+	Item  T
+	Score float64
+}
+
+type Option func(*options)
+
+type options struct {
+	keyField      string
+	candidates    int
+	rrfConstant   int
+	vectorOptions []vectordb.SearchOption
+	judge         Judge
+	rerankTop     int
+	indexOptions  []IndexOption
+}
+
+// WithKeyField sets the payload field identifying items in both the keyword index and the vector store, _id by default
+func WithKeyField(name string) Option {
+	return func(o *options) {
+		o.keyField = name
+	}
+}
+
+// WithCandidates sets how many items each of keyword and vector search contributes to the fusion
+func WithCandidates(n int) Option {
+	return func(o *options) {
+		o.candidates = n
+	}
+}
+
+// WithRRFConstant sets the k constant of reciprocal rank fusion
+func WithRRFConstant(k int) Option {
+	return func(o *options) {
+		o.rrfConstant = k
+	}
+}
+
+// WithVectorOptions passes options like filters or a score threshold to the vector search, keyword search is not affected
+// and the limit is set by candidates
+func WithVectorOptions(opts ...vectordb.SearchOption) Option {
+	return func(o *options) {
+		o.vectorOptions = append(o.vectorOptions, opts...)
+	}
+}
+
+// WithReranking lets the judge reorder the top fused items, only items it keeps are returned so nothing is found
+// when it keeps none
+func WithReranking(judge Judge, top int) Option {
+	return func(o *options) {
+		o.judge = judge
+		o.rerankTop = top
+	}
+}
+
+// WithIndexOptions tunes the BM25 keyword index
+func WithIndexOptions(opts ...IndexOption) Option {
+	return func(o *options) {
+		o.indexOptions = append(o.indexOptions, opts...)
+	}
+}
+
+// Hybrid finds items with both BM25 keyword search over chosen payload fields and vector similarity search,
+// rankings of both are merged with reciprocal rank fusion. Items are structs tagged for vectordb.
+type Hybrid[T any] struct {
+	db         Searcher
+	embeddor   Embeddor
+	collection string
+	fields     []string
+	opts       options
+	index      *Index
+
+	mu    sync.RWMutex
+	items map[string]T
+}
+
+// NewHybrid creates a retriever over the collection, the keyword index covers the payload fields and is empty until Index is called
+func NewHybrid[T any](db Searcher, embeddor Embeddor, collection string, fields []string, opts ...Option) *Hybrid[T] {
+	o := options{
+		keyField:    idField,
+		candidates:  DefaultCandidates,
+		rrfConstant: DefaultRRFConstant,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Hybrid[T]{
+		db:         db,
+		embeddor:   embeddor,
+		collection: collection,
+		fields:     fields,
+		opts:       o,
+		index:      NewIndex(o.indexOptions...),
+		items:      make(map[string]T),
+	}
+}
+
+// Index adds items to the keyword index, they are expected to be stored in the vector store as well
+func (h *Hybrid[T]) Index(items []T) error {
+	for _, item := range items {
+		key, text, err := h.describe(item)
+		if err != nil {
+			return fmt.Errorf("failed to index item: %v", err)
+		}
+		h.index.Add(key, text)
+		h.mu.Lock()
+		h.items[key] = item
+		h.mu.Unlock()
+	}
+	return nil
+}
+
+// Search returns at most limit items relevant to the query, the best match first
+func (h *Hybrid[T]) Search(ctx context.Context, query string, limit int) ([]Result[T], error) {
+	keywordHits := h.index.Search(query, h.opts.candidates)
+	keywordRanking := make([]string, len(keywordHits))
+	for i, hit := range keywordHits {
+		keywordRanking[i] = hit.Key
+	}
+
+	vector, err := h.embeddor.ModeratedEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding for query '%s': %v", query, err)
+	}
+	var found []T
+	searchOpts := append([]vectordb.SearchOption{vectordb.WithLimit(uint64(h.opts.candidates))}, h.opts.vectorOptions...)
+	if err := h.db.Search(ctx, h.collection, vector, &found, searchOpts...); err != nil {
+		return nil, fmt.Errorf("failed to search vector store: %v", err)
+	}
+
+	h.mu.RLock()
+	items := make(map[string]T, len(keywordHits)+len(found))
+	for _, key := range keywordRanking {
+		items[key] = h.items[key]
+	}
+	h.mu.RUnlock()
+	vectorRanking := make([]string, len(found))
+	for i, item := range found {
+		key, _, err := h.describe(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to identify found item: %v", err)
+		}
+		vectorRanking[i] = key
+		// the found item carries fields like _score which the indexed one does not
+		items[key] = item
+	}
+
+	fused := Fuse(h.opts.rrfConstant, keywordRanking, vectorRanking)
+	results := make([]Result[T], len(fused))
+	for i, hit := range fused {
+		results[i] = Result[T]{Item: items[hit.Key], Score: hit.Score}
+	}
+	if h.opts.judge != nil && len(results) != 0 {
+		results, err = h.rerank(ctx, query, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results[:min(limit, len(results))], nil
+}
+
+func (h *Hybrid[T]) rerank(ctx context.Context, query string, results []Result[T]) ([]Result[T], error) {
+	top := min(max(h.opts.rerankTop, 1), len(results))
+	passages := make([]string, top)
+	for i, r := range results[:top] {
+		_, text, err := h.describe(r.Item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe item for reranking: %v", err)
+		}
+		passages[i] = text
+	}
+	order, err := h.opts.judge.Rerank(ctx, query, passages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank results: %v", err)
+	}
+	// items past the top were never judged, they must not take the place of the rejected ones
+	reranked := make([]Result[T], 0, len(order))
+	for _, i := range order {
+		reranked = append(reranked, results[i])
+	}
+	return reranked, nil
+}
+
+// describe returns the key of the item and the text of its indexed fields
+func (h *Hybrid[T]) describe(item T) (string, string, error) {
+	p, err := vectordb.Marshal(item)
+	if err != nil {
+		return "", "", err
+	}
+	key := p.GetId().GetUuid()
+	if h.opts.keyField != idField {
+		key = p.GetPayload()[h.opts.keyField].GetStringValue()
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("item has no %s", h.opts.keyField)
+	}
+	var parts []string
+	for _, field := range h.fields {
+		parts = append(parts, valueText(p.GetPayload()[field])...)
+	}
+	return key, strings.Join(parts, "\n"), nil
+}
+
+// valueText returns texts of strings and numbers, lists and nested structs included
+func valueText(v *qdrant.Value) []string {
+	switch kind := v.GetKind().(type) {
+	case *qdrant.Value_StringValue:
+		return []string{kind.StringValue}
+	case *qdrant.Value_IntegerValue:
+		return []string{strconv.FormatInt(kind.IntegerValue, 10)}
+	case *qdrant.Value_DoubleValue:
+		return []string{strconv.FormatFloat(kind.DoubleValue, 'f', -1, 64)}
+	case *qdrant.Value_ListValue:
+		var result []string
+		for _, elem := range kind.ListValue.GetValues() {
+			result = append(result, valueText(elem)...)
+		}
+		return result
+	case *qdrant.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var result []string
+		for _, name := range names {
+			result = append(result, valueText(fields[name])...)
+		}
+		return result
+	}
+	return nil
+}
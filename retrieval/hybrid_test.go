@@ -0,0 +1,211 @@
+package retrieval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/koenno/aidevs2/ai"
+	"github.com/koenno/aidevs2/vectordb"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const collectionName = "some-collection"
+
+type Article struct {
+	ID     string    `qdrant:"_id"`
+	Vector []float32 `qdrant:"_vector"`
+	Score  float32   `qdrant:"_score"`
+	Title  string    `qdrant:"title"`
+	Info   string    `qdrant:"info"`
+	URL    string    `qdrant:"url"`
+}
+
+// fakeEmbeddor returns vectors by text, unknown texts point along the last axis
+type fakeEmbeddor map[string][]float32
+
+func (e fakeEmbeddor) ModeratedEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if v, exist := e[text]; exist {
+		return v, nil
+	}
+	return vector(0, 0, 1), nil
+}
+
+type fakeJudge struct {
+	order    []int
+	passages []string
+}
+
+func (j *fakeJudge) Rerank(ctx context.Context, question string, passages []string) ([]int, error) {
+	j.passages = passages
+	return j.order, nil
+}
+
+func vector(values ...float32) []float32 {
+	v := make([]float32, vectordb.VectorSize)
+	copy(v, values)
+	return v
+}
+
+func articles() []Article {
+	return []Article{
+		{ID: "2f1d8a4e-0000-4000-8000-000000000001", Vector: vector(1, 0), Title: "Ochrona danych", Info: "RODO weszło w życie 25 maja 2018", URL: "http://rodo"},
+		{ID: "2f1d8a4e-0000-4000-8000-000000000002", Vector: vector(0.9, 0.1), Title: "Prywatność w sieci", Info: "ciasteczka i zgody", URL: "http://cookies"},
+		{ID: "2f1d8a4e-0000-4000-8000-000000000003", Vector: vector(0, 1), Title: "Przepis na pizzę", Info: "Neapol", URL: "http://pizza"},
+	}
+}
+
+func newStore(t *testing.T) *vectordb.Memory {
+	ctx := context.Background()
+	db, err := vectordb.NewMemory()
+	require.NoError(t, err)
+	require.NoError(t, db.CreateCollection(ctx, collectionName))
+	var items []any
+	for _, a := range articles() {
+		items = append(items, a)
+	}
+	require.NoError(t, db.UpsertMany(ctx, collectionName, items))
+	return db
+}
+
+func urls(results []Result[Article]) []string {
+	result := make([]string, len(results))
+	for i, r := range results {
+		result[i] = r.Item.URL
+	}
+	return result
+}
+
+func TestShouldFuseRankingsWithReciprocalRankFusion(t *testing.T) {
+	// when
+	hits := Fuse(60, []string{"a", "b", "c"}, []string{"c", "a"})
+
+	// then
+	require.Len(t, hits, 3)
+	assert.Equal(t, []string{"a", "c", "b"}, keys(hits))
+	assert.InDelta(t, 1.0/61+1.0/62, hits[0].Score, 1e-9)
+	assert.InDelta(t, 1.0/63+1.0/61, hits[1].Score, 1e-9)
+	assert.InDelta(t, 1.0/62, hits[2].Score, 1e-9)
+}
+
+func TestShouldCombineKeywordAndVectorSearch(t *testing.T) {
+	// given
+	embeddor := fakeEmbeddor{"prywatność 2018": vector(0.9, 0.1)}
+	sut := NewHybrid[Article](newStore(t), embeddor, collectionName, []string{"title", "info"}, WithCandidates(2))
+	require.NoError(t, sut.Index(articles()))
+
+	// when
+	results, err := sut.Search(context.Background(), "prywatność 2018", 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://cookies", "http://rodo"}, urls(results))
+	assert.Greater(t, results[0].Score, results[1].Score)
+	assert.InDelta(t, 1, results[0].Item.Score, 1e-6, "found items keep the vector score")
+}
+
+func TestShouldFindExactKeywordsMissedByVectorSearch(t *testing.T) {
+	// given
+	sut := NewHybrid[Article](newStore(t), fakeEmbeddor{}, collectionName, []string{"title", "info"},
+		WithVectorOptions(vectordb.WithScoreThreshold(0.5)))
+	require.NoError(t, sut.Index(articles()))
+
+	// when
+	results, err := sut.Search(context.Background(), "Neapol", 1)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://pizza"}, urls(results))
+}
+
+func TestShouldIdentifyItemsByKeyField(t *testing.T) {
+	// given
+	indexed := articles()
+	for i := range indexed {
+		indexed[i].ID = ""
+	}
+	embeddor := fakeEmbeddor{"ochrona danych": vector(1, 0)}
+	sut := NewHybrid[Article](newStore(t), embeddor, collectionName, []string{"title"}, WithKeyField("url"))
+	require.NoError(t, sut.Index(indexed))
+
+	// when
+	results, err := sut.Search(context.Background(), "ochrona danych", 1)
+
+	// then
+	assert.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "http://rodo", results[0].Item.URL)
+	assert.InDelta(t, 2.0/61, results[0].Score, 1e-9, "both rankings agree on the item")
+}
+
+func TestShouldRejectItemsWithoutKey(t *testing.T) {
+	// given
+	sut := NewHybrid[Article](newStore(t), fakeEmbeddor{}, collectionName, []string{"title"}, WithKeyField("missing"))
+
+	// when
+	err := sut.Index(articles())
+
+	// then
+	assert.ErrorContains(t, err, "item has no missing")
+}
+
+func TestShouldRerankTopResults(t *testing.T) {
+	// given
+	judge := &fakeJudge{order: []int{1}}
+	embeddor := fakeEmbeddor{"ochrona danych": vector(1, 0)}
+	sut := NewHybrid[Article](newStore(t), embeddor, collectionName, []string{"title", "info"}, WithReranking(judge, 2))
+	require.NoError(t, sut.Index(articles()))
+
+	// when
+	results, err := sut.Search(context.Background(), "ochrona danych", 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://cookies"}, urls(results), "neither the left out nor the unjudged results are returned")
+	assert.Equal(t, []string{"Ochrona danych\nRODO weszło w życie 25 maja 2018", "Prywatność w sieci\nciasteczka i zgody"}, judge.passages)
+}
+
+func TestShouldFindNothingWhenJudgeKeepsNothing(t *testing.T) {
+	// given
+	judge := &fakeJudge{order: []int{}}
+	embeddor := fakeEmbeddor{"ochrona danych": vector(1, 0)}
+	sut := NewHybrid[Article](newStore(t), embeddor, collectionName, []string{"title", "info"}, WithReranking(judge, 2))
+	require.NoError(t, sut.Index(articles()))
+
+	// when
+	results, err := sut.Search(context.Background(), "ochrona danych", 10)
+
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Len(t, judge.passages, 2)
+}
+
+func TestShouldRerankWithModelJudgement(t *testing.T) {
+	// given
+	provider := ai.NewScripted(ai.Script{Chat: []ai.ScriptedReply{
+		{Match: "Passages:", Call: &openai.FunctionCall{Name: "respond", Arguments: `{"ranking": [3, 1, 7]}`}},
+	}})
+	sut := NewReranker(ai.NewChat(provider))
+
+	// when
+	order, err := sut.Rerank(context.Background(), "Kiedy weszło RODO?", []string{"pizza", "RODO\n2018", "sieć"})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 0}, order, "passages which do not exist are skipped")
+	require.Len(t, provider.Requests(), 1)
+	assert.Contains(t, provider.Requests()[0].Messages[1].Content, "Question: Kiedy weszło RODO?\n\nPassages:\n1. pizza\n2. RODO 2018\n3. sieć\n")
+}
+
+func TestShouldAskAgainForDuplicatedPassages(t *testing.T) {
+	// given
+	sut := judgement{Ranking: []int{2, 1, 2}}
+
+	// when
+	err := sut.Validate()
+
+	// then
+	assert.ErrorContains(t, err, "passage 2 is listed more than once")
+}
@@ -0,0 +1,68 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koenno/aidevs2/ai"
+)
+
+const rerankSystem = `You judge how relevant passages are to a question.
+Respond with the numbers of the passages which help to answer the question, the most relevant first.
+Leave out passages which are not relevant at all.`
+
+type Structurer interface {
+	ModeratedCompleteInto(ctx context.Context, conv *ai.Conversation, target any, opts ...ai.StructuredOption) error
+}
+
+// Reranker orders passages by an LLM judgement of their relevance to the question
+type Reranker struct {
+	structurer Structurer
+}
+
+func NewReranker(structurer Structurer) Reranker {
+	return Reranker{
+		structurer: structurer,
+	}
+}
+
+type judgement struct {
+	Ranking []int `json:"ranking" description:"numbers of relevant passages, the most relevant first"`
+}
+
+func (j *judgement) Validate() error {
+	seen := make(map[int]bool, len(j.Ranking))
+	for _, n := range j.Ranking {
+		if n < 1 {
+			return fmt.Errorf("passage %d does not exist, passages are numbered from 1", n)
+		}
+		if seen[n] {
+			return fmt.Errorf("passage %d is listed more than once", n)
+		}
+		seen[n] = true
+	}
+	return nil
+}
+
+// Rerank returns indexes of passages judged relevant, the most relevant first
+func (r Reranker) Rerank(ctx context.Context, question string, passages []string) ([]int, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Question: %s\n\nPassages:\n", question)
+	for i, p := range passages {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, strings.ReplaceAll(p, "\n", " "))
+	}
+	conv := ai.NewConversation(rerankSystem).AddUser(sb.String())
+	var j judgement
+	if err := r.structurer.ModeratedCompleteInto(ctx, conv, &j); err != nil {
+		return nil, fmt.Errorf("failed to judge relevance of passages: %v", err)
+	}
+	order := make([]int, 0, len(j.Ranking))
+	for _, n := range j.Ranking {
+		// the target is reset before decoding so the number of passages can not be validated, made up passages are skipped
+		if n <= len(passages) {
+			order = append(order, n-1)
+		}
+	}
+	return order, nil
+}
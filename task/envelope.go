@@ -0,0 +1,41 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Envelope is a task with a typed payload, the payload is decoded from the same JSON object as code and msg
+type Envelope[T any] struct {
+	Token   string
+	Code    int
+	Msg     string
+	Payload T
+}
+
+func (e *Envelope[T]) GetCode() int {
+	return e.Code
+}
+
+func (e *Envelope[T]) GetMsg() string {
+	return e.Msg
+}
+
+func (e *Envelope[T]) SetToken(token string) {
+	e.Token = token
+}
+
+func (e *Envelope[T]) UnmarshalJSON(bb []byte) error {
+	var resp Response
+	if err := json.Unmarshal(bb, &resp); err != nil {
+		return fmt.Errorf("failed to decode task response: %v", err)
+	}
+	var payload T
+	if err := json.Unmarshal(bb, &payload); err != nil {
+		return fmt.Errorf("failed to decode task payload: %v", err)
+	}
+	e.Code = resp.Code
+	e.Msg = resp.Msg
+	e.Payload = payload
+	return nil
+}
@@ -0,0 +1,44 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPayload struct {
+	Question string   `json:"question"`
+	Input    []string `json:"input"`
+}
+
+func TestShouldDecodeEnvelopeWithPayload(t *testing.T) {
+	// given
+	raw := `{"code":0,"msg":"some message","question":"some question","input":["a","b"]}`
+	var sut Envelope[testPayload]
+
+	// when
+	err := json.Unmarshal([]byte(raw), &sut)
+	sut.SetToken("some token")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, Envelope[testPayload]{
+		Token:   "some token",
+		Msg:     "some message",
+		Payload: testPayload{Question: "some question", Input: []string{"a", "b"}},
+	}, sut)
+	assert.Equal(t, "some message", sut.GetMsg())
+}
+
+func TestShouldFailWhenPayloadDoesNotMatch(t *testing.T) {
+	// given
+	raw := `{"code":0,"msg":"some message","question":7}`
+	var sut Envelope[testPayload]
+
+	// when
+	err := json.Unmarshal([]byte(raw), &sut)
+
+	// then
+	assert.Error(t, err)
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	http "net/http"
 
 	mock "github.com/stretchr/testify/mock"
@@ -21,25 +23,25 @@ func (_m *AnswerRequestCreator) EXPECT() *AnswerRequestCreator_Expecter {
 	return &AnswerRequestCreator_Expecter{mock: &_m.Mock}
 }
 
-// Answer provides a mock function with given fields: token, answerStr
-func (_m *AnswerRequestCreator) Answer(token string, answerStr string) (*http.Request, error) {
-	ret := _m.Called(token, answerStr)
+// Answer provides a mock function with given fields: ctx, token, answerData
+func (_m *AnswerRequestCreator) Answer(ctx context.Context, token string, answerData interface{}) (*http.Request, error) {
+	ret := _m.Called(ctx, token, answerData)
 
 	var r0 *http.Request
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*http.Request, error)); ok {
-		return rf(token, answerStr)
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}) (*http.Request, error)); ok {
+		return rf(ctx, token, answerData)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *http.Request); ok {
-		r0 = rf(token, answerStr)
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}) *http.Request); ok {
+		r0 = rf(ctx, token, answerData)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*http.Request)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(token, answerStr)
+	if rf, ok := ret.Get(1).(func(context.Context, string, interface{}) error); ok {
+		r1 = rf(ctx, token, answerData)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -53,15 +55,16 @@ type AnswerRequestCreator_Answer_Call struct {
 }
 
 // Answer is a helper method to define mock.On call
+//   - ctx context.Context
 //   - token string
-//   - answerStr string
-func (_e *AnswerRequestCreator_Expecter) Answer(token interface{}, answerStr interface{}) *AnswerRequestCreator_Answer_Call {
-	return &AnswerRequestCreator_Answer_Call{Call: _e.mock.On("Answer", token, answerStr)}
+//   - answerData interface{}
+func (_e *AnswerRequestCreator_Expecter) Answer(ctx interface{}, token interface{}, answerData interface{}) *AnswerRequestCreator_Answer_Call {
+	return &AnswerRequestCreator_Answer_Call{Call: _e.mock.On("Answer", ctx, token, answerData)}
 }
 
-func (_c *AnswerRequestCreator_Answer_Call) Run(run func(token string, answerStr string)) *AnswerRequestCreator_Answer_Call {
+func (_c *AnswerRequestCreator_Answer_Call) Run(run func(ctx context.Context, token string, answerData interface{})) *AnswerRequestCreator_Answer_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(interface{}))
 	})
 	return _c
 }
@@ -71,7 +74,7 @@ func (_c *AnswerRequestCreator_Answer_Call) Return(_a0 *http.Request, _a1 error)
 	return _c
 }
 
-func (_c *AnswerRequestCreator_Answer_Call) RunAndReturn(run func(string, string) (*http.Request, error)) *AnswerRequestCreator_Answer_Call {
+func (_c *AnswerRequestCreator_Answer_Call) RunAndReturn(run func(context.Context, string, interface{}) (*http.Request, error)) *AnswerRequestCreator_Answer_Call {
 	_c.Call.Return(run)
 	return _c
 }
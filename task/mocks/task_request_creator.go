@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	http "net/http"
 
 	mock "github.com/stretchr/testify/mock"
@@ -21,25 +23,25 @@ func (_m *TaskRequestCreator) EXPECT() *TaskRequestCreator_Expecter {
 	return &TaskRequestCreator_Expecter{mock: &_m.Mock}
 }
 
-// Authenticate provides a mock function with given fields: apiKey, taskName
-func (_m *TaskRequestCreator) Authenticate(apiKey string, taskName string) (*http.Request, error) {
-	ret := _m.Called(apiKey, taskName)
+// Authenticate provides a mock function with given fields: ctx, apiKey, taskName
+func (_m *TaskRequestCreator) Authenticate(ctx context.Context, apiKey string, taskName string) (*http.Request, error) {
+	ret := _m.Called(ctx, apiKey, taskName)
 
 	var r0 *http.Request
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*http.Request, error)); ok {
-		return rf(apiKey, taskName)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*http.Request, error)); ok {
+		return rf(ctx, apiKey, taskName)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *http.Request); ok {
-		r0 = rf(apiKey, taskName)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *http.Request); ok {
+		r0 = rf(ctx, apiKey, taskName)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*http.Request)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(apiKey, taskName)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, apiKey, taskName)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -53,15 +55,16 @@ type TaskRequestCreator_Authenticate_Call struct {
 }
 
 // Authenticate is a helper method to define mock.On call
+//   - ctx context.Context
 //   - apiKey string
 //   - taskName string
-func (_e *TaskRequestCreator_Expecter) Authenticate(apiKey interface{}, taskName interface{}) *TaskRequestCreator_Authenticate_Call {
-	return &TaskRequestCreator_Authenticate_Call{Call: _e.mock.On("Authenticate", apiKey, taskName)}
+func (_e *TaskRequestCreator_Expecter) Authenticate(ctx interface{}, apiKey interface{}, taskName interface{}) *TaskRequestCreator_Authenticate_Call {
+	return &TaskRequestCreator_Authenticate_Call{Call: _e.mock.On("Authenticate", ctx, apiKey, taskName)}
 }
 
-func (_c *TaskRequestCreator_Authenticate_Call) Run(run func(apiKey string, taskName string)) *TaskRequestCreator_Authenticate_Call {
+func (_c *TaskRequestCreator_Authenticate_Call) Run(run func(ctx context.Context, apiKey string, taskName string)) *TaskRequestCreator_Authenticate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
@@ -71,30 +74,30 @@ func (_c *TaskRequestCreator_Authenticate_Call) Return(_a0 *http.Request, _a1 er
 	return _c
 }
 
-func (_c *TaskRequestCreator_Authenticate_Call) RunAndReturn(run func(string, string) (*http.Request, error)) *TaskRequestCreator_Authenticate_Call {
+func (_c *TaskRequestCreator_Authenticate_Call) RunAndReturn(run func(context.Context, string, string) (*http.Request, error)) *TaskRequestCreator_Authenticate_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Task provides a mock function with given fields: token
-func (_m *TaskRequestCreator) Task(token string) (*http.Request, error) {
-	ret := _m.Called(token)
+// Task provides a mock function with given fields: ctx, token
+func (_m *TaskRequestCreator) Task(ctx context.Context, token string) (*http.Request, error) {
+	ret := _m.Called(ctx, token)
 
 	var r0 *http.Request
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*http.Request, error)); ok {
-		return rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*http.Request, error)); ok {
+		return rf(ctx, token)
 	}
-	if rf, ok := ret.Get(0).(func(string) *http.Request); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *http.Request); ok {
+		r0 = rf(ctx, token)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*http.Request)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -108,14 +111,15 @@ type TaskRequestCreator_Task_Call struct {
 }
 
 // Task is a helper method to define mock.On call
+//   - ctx context.Context
 //   - token string
-func (_e *TaskRequestCreator_Expecter) Task(token interface{}) *TaskRequestCreator_Task_Call {
-	return &TaskRequestCreator_Task_Call{Call: _e.mock.On("Task", token)}
+func (_e *TaskRequestCreator_Expecter) Task(ctx interface{}, token interface{}) *TaskRequestCreator_Task_Call {
+	return &TaskRequestCreator_Task_Call{Call: _e.mock.On("Task", ctx, token)}
 }
 
-func (_c *TaskRequestCreator_Task_Call) Run(run func(token string)) *TaskRequestCreator_Task_Call {
+func (_c *TaskRequestCreator_Task_Call) Run(run func(ctx context.Context, token string)) *TaskRequestCreator_Task_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -125,7 +129,7 @@ func (_c *TaskRequestCreator_Task_Call) Return(_a0 *http.Request, _a1 error) *Ta
 	return _c
 }
 
-func (_c *TaskRequestCreator_Task_Call) RunAndReturn(run func(string) (*http.Request, error)) *TaskRequestCreator_Task_Call {
+func (_c *TaskRequestCreator_Task_Call) RunAndReturn(run func(context.Context, string) (*http.Request, error)) *TaskRequestCreator_Task_Call {
 	_c.Call.Return(run)
 	return _c
 }
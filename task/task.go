@@ -1,15 +1,21 @@
 package task
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+
+	"github.com/koenno/aidevs2/client/aidevs"
 )
 
 var (
 	ErrFetch  = errors.New("failed to fetch a task")
 	ErrAnswer = errors.New("failed to send answer")
+	// ErrRejected is returned when the server judged the answer wrong
+	ErrRejected = errors.New("answer rejected")
 
 	errAuth = errors.New("failed to authenticate")
 	errTask = errors.New("failed to fetch task")
@@ -22,8 +28,8 @@ type Client interface {
 
 //go:generate mockery --name=TaskRequestCreator --case underscore --with-expecter
 type TaskRequestCreator interface {
-	Authenticate(apiKey, taskName string) (*http.Request, error)
-	Task(token string) (*http.Request, error)
+	Authenticate(ctx context.Context, apiKey, taskName string) (*http.Request, error)
+	Task(ctx context.Context, token string) (*http.Request, error)
 }
 
 type Fetcher struct {
@@ -38,13 +44,13 @@ type AIDevsTask interface {
 	SetToken(string)
 }
 
-func (s Fetcher) Fetch(taskName string, resp AIDevsTask) error {
-	token, err := s.authenticate(taskName)
+func (s Fetcher) Fetch(ctx context.Context, taskName string, resp AIDevsTask) error {
+	token, err := s.authenticate(ctx, taskName)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFetch, err)
 	}
 
-	err = s.fetchTask(token, resp)
+	err = s.fetchTask(ctx, token, resp)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFetch, err)
 	}
@@ -62,15 +68,15 @@ type AuthorizationResponse struct {
 	Token string `json:"token"`
 }
 
-func (s Fetcher) authenticate(taskName string) (string, error) {
-	req, err := s.Creator.Authenticate(s.ApiKey, taskName)
+func (s Fetcher) authenticate(ctx context.Context, taskName string) (string, error) {
+	req, err := s.Creator.Authenticate(ctx, s.ApiKey, taskName)
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", errAuth, err)
 	}
 	var resp AuthorizationResponse
 	err = s.Client.Send(req, &resp)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", errAuth, err)
+		return "", fmt.Errorf("%w: %w", errAuth, err)
 	}
 	if resp.Code != 0 {
 		return "", fmt.Errorf("%w: error response: %d - %s", errAuth, resp.Code, resp.Msg)
@@ -82,14 +88,14 @@ func (s Fetcher) authenticate(taskName string) (string, error) {
 	return resp.Token, nil
 }
 
-func (s Fetcher) fetchTask(token string, resp AIDevsTask) error {
-	req, err := s.Creator.Task(token)
+func (s Fetcher) fetchTask(ctx context.Context, token string, resp AIDevsTask) error {
+	req, err := s.Creator.Task(ctx, token)
 	if err != nil {
 		return fmt.Errorf("%w: %v", errTask, err)
 	}
 	err = s.Client.Send(req, resp)
 	if err != nil {
-		return fmt.Errorf("%w: %v", errTask, err)
+		return fmt.Errorf("%w: %w", errTask, err)
 	}
 	if resp.GetCode() != 0 {
 		return fmt.Errorf("%w: error response: %d - %s", errTask, resp.GetCode(), resp.GetMsg())
@@ -100,7 +106,7 @@ func (s Fetcher) fetchTask(token string, resp AIDevsTask) error {
 
 //go:generate mockery --name=AnswerRequestCreator --case underscore --with-expecter
 type AnswerRequestCreator interface {
-	Answer(token string, answerData any) (*http.Request, error)
+	Answer(ctx context.Context, token string, answerData any) (*http.Request, error)
 }
 
 type Answerer struct {
@@ -108,19 +114,86 @@ type Answerer struct {
 	Creator AnswerRequestCreator
 }
 
-func (a Answerer) Answer(token string, answerData any) error {
-	req, err := a.Creator.Answer(token, answerData)
+// Verdict is the server's judgement of an answer
+type Verdict struct {
+	Accepted bool
+	Code     int
+	Message  string
+	// Extra holds the remaining response fields like note, reply or hint
+	Extra map[string]any
+}
+
+// Feedback describes the verdict in a form which can be given back to a solver
+func (v Verdict) Feedback() string {
+	feedback := v.Message
+	keys := make([]string, 0, len(v.Extra))
+	for k := range v.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		feedback += fmt.Sprintf("\n%s: %v", k, v.Extra[k])
+	}
+	return feedback
+}
+
+// Answer sends the answer, a rejected answer returns its verdict together with ErrRejected.
+// An error status with a JSON body is a rejection too, other failures return no verdict.
+func (a Answerer) Answer(ctx context.Context, token string, answerData any) (Verdict, error) {
+	req, err := a.Creator.Answer(ctx, token, answerData)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrAnswer, err)
+		return Verdict{}, fmt.Errorf("%w: %v", ErrAnswer, err)
 	}
-	var resp Response
+	var resp map[string]any
 	err = a.Client.Send(req, &resp)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrAnswer, err)
+	var apiErr *aidevs.Error
+	rejected := errors.As(err, &apiErr) && (apiErr.Body != nil || apiErr.Code != 0)
+	if rejected {
+		// the whole body is kept as note or reply matter most for wrong answers
+		resp = apiErr.Body
+		if resp == nil {
+			resp = map[string]any{
+				"code": apiErr.Code,
+				"msg":  apiErr.Message,
+			}
+			if apiErr.Hint != "" {
+				resp["hint"] = apiErr.Hint
+			}
+		}
+	} else if err != nil {
+		return Verdict{}, fmt.Errorf("%w: %w", ErrAnswer, err)
 	}
-	if resp.Code != 0 {
-		return fmt.Errorf("%w: error response: %d - %s", ErrAnswer, resp.Code, resp.Msg)
+	verdict := newVerdict(resp)
+	if rejected {
+		// an error status rejects the answer even when the body has no code
+		verdict.Accepted = false
 	}
-	log.Printf("answer resp message: %s", resp.Msg)
-	return nil
+	if !verdict.Accepted {
+		return verdict, fmt.Errorf("%w: %w: %d - %s", ErrAnswer, ErrRejected, verdict.Code, verdict.Message)
+	}
+	log.Printf("answer resp message: %s", verdict.Message)
+	return verdict, nil
+}
+
+func newVerdict(resp map[string]any) Verdict {
+	var v Verdict
+	for k, val := range resp {
+		switch k {
+		case "code":
+			if code, ok := val.(float64); ok {
+				v.Code = int(code)
+			} else if code, ok := val.(int); ok {
+				v.Code = code
+			}
+		case "msg":
+			v.Message = fmt.Sprint(val)
+		default:
+			if v.Extra == nil {
+				v.Extra = make(map[string]any)
+			}
+			v.Extra[k] = val
+		}
+	}
+	v.Accepted = v.Code == 0
+	return v
 }
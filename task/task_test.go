@@ -1,10 +1,12 @@
 package task
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
 
+	"github.com/koenno/aidevs2/client/aidevs"
 	"github.com/koenno/aidevs2/task/mocks"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -56,10 +58,10 @@ func (s *FetcherTestSuite) TestShouldReturnErrorWhenAuthRequestCreationFails() {
 	var payload TestTaskData
 
 	expectedErr := errors.New("fatal failure")
-	s.reqCreatorMock.EXPECT().Authenticate(s.sut.ApiKey, s.taskName).Return(nil, expectedErr).Once()
+	s.reqCreatorMock.EXPECT().Authenticate(mock.Anything, s.sut.ApiKey, s.taskName).Return(nil, expectedErr).Once()
 
 	// when
-	err := s.sut.Fetch(s.taskName, &payload)
+	err := s.sut.Fetch(context.Background(), s.taskName, &payload)
 
 	// then
 	s.ErrorIs(err, errAuth)
@@ -71,13 +73,13 @@ func (s *FetcherTestSuite) TestShouldReturnErrorWhenSendingRequestFails() {
 	var payload TestTaskData
 
 	req, _ := http.NewRequest(http.MethodGet, "", nil)
-	s.reqCreatorMock.EXPECT().Authenticate(s.sut.ApiKey, s.taskName).Return(req, nil).Once()
+	s.reqCreatorMock.EXPECT().Authenticate(mock.Anything, s.sut.ApiKey, s.taskName).Return(req, nil).Once()
 
 	expectedErr := errors.New("fatal failure")
 	s.clientMock.EXPECT().Send(req, mock.Anything).Return(expectedErr).Once()
 
 	// when
-	err := s.sut.Fetch(s.taskName, &payload)
+	err := s.sut.Fetch(context.Background(), s.taskName, &payload)
 
 	// then
 	s.ErrorIs(err, errAuth)
@@ -89,7 +91,7 @@ func (s *FetcherTestSuite) TestShouldReturnErrorWhenAuthorizationResponseCodeIsN
 	var payload TestTaskData
 
 	req, _ := http.NewRequest(http.MethodGet, "", nil)
-	s.reqCreatorMock.EXPECT().Authenticate(s.sut.ApiKey, s.taskName).Return(req, nil).Once()
+	s.reqCreatorMock.EXPECT().Authenticate(mock.Anything, s.sut.ApiKey, s.taskName).Return(req, nil).Once()
 
 	s.clientMock.EXPECT().Send(req, mock.Anything).Run(func(r *http.Request, respPayload interface{}) {
 		respPayload = Response{
@@ -99,7 +101,7 @@ func (s *FetcherTestSuite) TestShouldReturnErrorWhenAuthorizationResponseCodeIsN
 	}).Return(nil).Once()
 
 	// when
-	err := s.sut.Fetch(s.taskName, &payload)
+	err := s.sut.Fetch(context.Background(), s.taskName, &payload)
 
 	// then
 	s.ErrorIs(err, errAuth)
@@ -111,7 +113,7 @@ func (s *FetcherTestSuite) TestShouldReturnErrorWhenAuthorizationResponseTokenIs
 	var payload TestTaskData
 
 	req, _ := http.NewRequest(http.MethodGet, "", nil)
-	s.reqCreatorMock.EXPECT().Authenticate(s.sut.ApiKey, s.taskName).Return(req, nil).Once()
+	s.reqCreatorMock.EXPECT().Authenticate(mock.Anything, s.sut.ApiKey, s.taskName).Return(req, nil).Once()
 
 	s.clientMock.EXPECT().Send(req, mock.Anything).Run(func(r *http.Request, respPayload interface{}) {
 		resp, ok := respPayload.(*AuthorizationResponse)
@@ -124,7 +126,7 @@ func (s *FetcherTestSuite) TestShouldReturnErrorWhenAuthorizationResponseTokenIs
 	}).Return(nil).Once()
 
 	// when
-	err := s.sut.Fetch(s.taskName, &payload)
+	err := s.sut.Fetch(context.Background(), s.taskName, &payload)
 
 	// then
 	s.ErrorIs(err, errAuth)
@@ -137,7 +139,7 @@ func (s *FetcherTestSuite) TestShouldFetchTask() {
 	token := "rghetrgt67ih"
 
 	reqAuth, _ := http.NewRequest(http.MethodPost, "", nil)
-	s.reqCreatorMock.EXPECT().Authenticate(s.sut.ApiKey, s.taskName).Return(reqAuth, nil).Once()
+	s.reqCreatorMock.EXPECT().Authenticate(mock.Anything, s.sut.ApiKey, s.taskName).Return(reqAuth, nil).Once()
 
 	s.clientMock.EXPECT().Send(reqAuth, mock.Anything).Run(func(r *http.Request, respPayload interface{}) {
 		resp, ok := respPayload.(*AuthorizationResponse)
@@ -150,7 +152,7 @@ func (s *FetcherTestSuite) TestShouldFetchTask() {
 	}).Return(nil).Once()
 
 	reqTask, _ := http.NewRequest(http.MethodGet, "", nil)
-	s.reqCreatorMock.EXPECT().Task(token).Return(reqTask, nil).Once()
+	s.reqCreatorMock.EXPECT().Task(mock.Anything, token).Return(reqTask, nil).Once()
 
 	expectedTaskData := TestTaskData{
 		Response: Response{
@@ -168,9 +170,111 @@ func (s *FetcherTestSuite) TestShouldFetchTask() {
 	}).Return(nil).Once()
 
 	// when
-	err := s.sut.Fetch(s.taskName, &payload)
+	err := s.sut.Fetch(context.Background(), s.taskName, &payload)
 
 	// then
 	s.NoError(err)
 	s.Equal(expectedTaskData, payload)
 }
+
+type AnswererTestSuite struct {
+	suite.Suite
+	clientMock     *mocks.Client
+	reqCreatorMock *mocks.AnswerRequestCreator
+	sut            Answerer
+	req            *http.Request
+}
+
+func TestAnswererTestSuite(t *testing.T) {
+	suite.Run(t, new(AnswererTestSuite))
+}
+
+func (s *AnswererTestSuite) SetupTest() {
+	s.clientMock = mocks.NewClient(s.T())
+	s.reqCreatorMock = mocks.NewAnswerRequestCreator(s.T())
+	s.sut = Answerer{
+		Client:  s.clientMock,
+		Creator: s.reqCreatorMock,
+	}
+	s.req, _ = http.NewRequest(http.MethodPost, "", nil)
+	s.reqCreatorMock.EXPECT().Answer(mock.Anything, "someToken", "someAnswer").Return(s.req, nil).Once()
+}
+
+func (s *AnswererTestSuite) TestShouldReturnAcceptedVerdictWithExtraFields() {
+	// given
+	s.clientMock.EXPECT().Send(s.req, mock.Anything).Run(func(r *http.Request, respPayload interface{}) {
+		resp := respPayload.(*map[string]any)
+		*resp = map[string]any{"code": float64(0), "msg": "OK", "note": "CORRECT"}
+	}).Return(nil).Once()
+
+	// when
+	verdict, err := s.sut.Answer(context.Background(), "someToken", "someAnswer")
+
+	// then
+	s.NoError(err)
+	s.Equal(Verdict{Accepted: true, Message: "OK", Extra: map[string]any{"note": "CORRECT"}}, verdict)
+}
+
+func (s *AnswererTestSuite) TestShouldReturnRejectedVerdictFromErrorResponse() {
+	// given
+	apiErr := &aidevs.Error{Status: http.StatusBadRequest, Code: -777, Message: "Answer is wrong", Hint: "look closer"}
+	s.clientMock.EXPECT().Send(s.req, mock.Anything).Return(apiErr).Once()
+
+	// when
+	verdict, err := s.sut.Answer(context.Background(), "someToken", "someAnswer")
+
+	// then
+	s.ErrorIs(err, ErrRejected)
+	s.ErrorIs(err, ErrAnswer)
+	s.Equal(Verdict{Code: -777, Message: "Answer is wrong", Extra: map[string]any{"hint": "look closer"}}, verdict)
+	s.Equal("Answer is wrong\nhint: look closer", verdict.Feedback())
+}
+
+func (s *AnswererTestSuite) TestShouldKeepAllFieldsOfErrorBodyInVerdict() {
+	// given
+	apiErr := &aidevs.Error{
+		Status:  http.StatusBadRequest,
+		Code:    -777,
+		Message: "Answer is wrong",
+		Body:    map[string]any{"code": -777.0, "msg": "Answer is wrong", "note": "INCORRECT", "reply": map[string]any{"answer": "someAnswer"}},
+	}
+	s.clientMock.EXPECT().Send(s.req, mock.Anything).Return(apiErr).Once()
+
+	// when
+	verdict, err := s.sut.Answer(context.Background(), "someToken", "someAnswer")
+
+	// then
+	s.ErrorIs(err, ErrRejected)
+	s.Equal(Verdict{Code: -777, Message: "Answer is wrong", Extra: map[string]any{"note": "INCORRECT", "reply": map[string]any{"answer": "someAnswer"}}}, verdict)
+}
+
+func (s *AnswererTestSuite) TestShouldRejectAnswerOnErrorStatusWithoutCode() {
+	// given
+	apiErr := &aidevs.Error{
+		Status:  http.StatusBadRequest,
+		Message: "wrong format",
+		Body:    map[string]any{"msg": "wrong format", "note": "answer should be a list"},
+	}
+	s.clientMock.EXPECT().Send(s.req, mock.Anything).Return(apiErr).Once()
+
+	// when
+	verdict, err := s.sut.Answer(context.Background(), "someToken", "someAnswer")
+
+	// then
+	s.ErrorIs(err, ErrRejected)
+	s.Equal(Verdict{Message: "wrong format", Extra: map[string]any{"note": "answer should be a list"}}, verdict)
+}
+
+func (s *AnswererTestSuite) TestShouldNotReturnVerdictWhenSendingFails() {
+	// given
+	expectedErr := errors.New("fatal failure")
+	s.clientMock.EXPECT().Send(s.req, mock.Anything).Return(expectedErr).Once()
+
+	// when
+	verdict, err := s.sut.Answer(context.Background(), "someToken", "someAnswer")
+
+	// then
+	s.ErrorIs(err, expectedErr)
+	s.NotErrorIs(err, ErrRejected)
+	s.Zero(verdict)
+}
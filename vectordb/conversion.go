@@ -1,41 +1,135 @@
 package vectordb
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	qdrant "github.com/qdrant/go-client/qdrant"
 )
 
 const (
 	tag = "qdrant"
+
+	idField     = "_id"
+	vectorField = "_vector"
+	scoreField  = "_score"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
 )
 
+// field is a tagged struct field, the index leads through embedded structs like in reflect.Value.FieldByIndex
+type field struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// fields lists tagged fields of the struct the way encoding/json does, fields of untagged embedded structs are promoted
+// and a shallower field hides a deeper one of the same name
+func fields(t reflect.Type) []field {
+	var result []field
+	depth := make(map[string]int)
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tagVal, tagged := sf.Tag.Lookup(tag)
+			name, opts, _ := strings.Cut(tagVal, ",")
+			idx := append(append([]int(nil), index...), i)
+			if name == "-" && opts == "" {
+				continue
+			}
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, idx)
+				continue
+			}
+			if !tagged || name == "" || !sf.IsExported() {
+				continue
+			}
+			if d, exist := depth[name]; exist && d <= len(idx) {
+				continue
+			}
+			depth[name] = len(idx)
+			result = append(result, field{
+				name:      name,
+				index:     idx,
+				typ:       sf.Type,
+				omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+			})
+		}
+	}
+	walk(t, nil)
+	// drop fields hidden by shallower ones found later
+	visible := result[:0]
+	for _, f := range result {
+		if depth[f.name] == len(f.index) {
+			visible = append(visible, f)
+		}
+	}
+	return visible
+}
+
+// fieldByIndex returns the field, nil embedded pointers are allocated when alloc is set, otherwise an invalid value is returned
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Marshal converts the struct into a point, payload fields follow encoding/json rules: a tag gives the name, omitempty skips
+// empty values, "-" skips the field and fields of untagged embedded structs are promoted, untagged fields are not stored
 func Marshal(item any) (*qdrant.PointStruct, error) {
 	result := &qdrant.PointStruct{}
 	payload := map[string]*qdrant.Value{}
-	t := reflect.TypeOf(item)
-	if t.Kind() != reflect.Struct {
+	value := reflect.ValueOf(item)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("item should be a struct, not a %T", item)
 	}
 
-	value := reflect.ValueOf(item)
-
-	for i := 0; i < value.NumField(); i++ {
-		field := value.Type().Field(i)
-		fieldValue := value.Field(i)
-
-		tagVal := field.Tag.Get(tag)
-		switch tagVal {
-		case "":
+	for _, f := range fields(value.Type()) {
+		fieldValue := fieldByIndex(value, f.index, false)
+		if !fieldValue.IsValid() {
 			continue
-		case "_id":
+		}
+		switch f.name {
+		case idField:
+			if fieldValue.Kind() != reflect.String {
+				return nil, fmt.Errorf("_id should be of type string, not %s", f.typ)
+			}
 			result.Id = &qdrant.PointId{
 				PointIdOptions: &qdrant.PointId_Uuid{
 					Uuid: fieldValue.String(),
 				},
 			}
-		case "_vector":
+		case scoreField:
+			continue
+		case vectorField:
 			slice, ok := fieldValue.Interface().([]float32)
 			if !ok {
 				return nil, fmt.Errorf("_vector should be of type []float32")
@@ -48,59 +142,387 @@ func Marshal(item any) (*qdrant.PointStruct, error) {
 				},
 			}
 		default:
-			if field.Type.Kind() != reflect.String {
-				return nil, fmt.Errorf("item payload field should be a string, not a %s", field.Type)
+			if f.omitEmpty && isEmpty(fieldValue) {
+				continue
 			}
-			payload[tagVal] = &qdrant.Value{
-				Kind: &qdrant.Value_StringValue{
-					StringValue: fieldValue.String(),
-				},
+			v, err := marshalValue(fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal payload field %s: %v", f.name, err)
 			}
+			payload[f.name] = v
 		}
 	}
 	result.Payload = payload
 	return result, nil
 }
 
+// isEmpty tells whether omitempty skips the value, the same values as in encoding/json are empty
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+func marshalValue(v reflect.Value) (*qdrant.Value, error) {
+	if v.Type() == timeType {
+		return stringValue(v.Interface().(time.Time).Format(time.RFC3339Nano)), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return stringValue(v.String()), nil
+	case reflect.Bool:
+		return &qdrant.Value{Kind: &qdrant.Value_BoolValue{BoolValue: v.Bool()}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &qdrant.Value{Kind: &qdrant.Value_IntegerValue{IntegerValue: v.Int()}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("value %d overflows int64", v.Uint())
+		}
+		return &qdrant.Value{Kind: &qdrant.Value_IntegerValue{IntegerValue: int64(v.Uint())}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &qdrant.Value{Kind: &qdrant.Value_DoubleValue{DoubleValue: v.Float()}}, nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nullValue(), nil
+		}
+		return marshalValue(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nullValue(), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return stringValue(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+		}
+		return marshalList(v)
+	case reflect.Array:
+		return marshalList(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nullValue(), nil
+		}
+		s := &qdrant.Struct{Fields: make(map[string]*qdrant.Value, v.Len())}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			elem, err := marshalValue(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			s.Fields[key] = elem
+		}
+		return &qdrant.Value{Kind: &qdrant.Value_StructValue{StructValue: s}}, nil
+	case reflect.Struct:
+		s := &qdrant.Struct{Fields: make(map[string]*qdrant.Value)}
+		for _, f := range fields(v.Type()) {
+			fieldValue := fieldByIndex(v, f.index, false)
+			if !fieldValue.IsValid() || f.omitEmpty && isEmpty(fieldValue) {
+				continue
+			}
+			elem, err := marshalValue(fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", f.name, err)
+			}
+			s.Fields[f.name] = elem
+		}
+		return &qdrant.Value{Kind: &qdrant.Value_StructValue{StructValue: s}}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func marshalList(v reflect.Value) (*qdrant.Value, error) {
+	list := &qdrant.ListValue{Values: make([]*qdrant.Value, v.Len())}
+	for i := 0; i < v.Len(); i++ {
+		elem, err := marshalValue(v.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %v", i, err)
+		}
+		list.Values[i] = elem
+	}
+	return &qdrant.Value{Kind: &qdrant.Value_ListValue{ListValue: list}}, nil
+}
+
+// mapKey converts the key like encoding/json, only string and integer keys are supported
+func mapKey(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", k.Type())
+}
+
+func stringValue(s string) *qdrant.Value {
+	return &qdrant.Value{Kind: &qdrant.Value_StringValue{StringValue: s}}
+}
+
+func nullValue() *qdrant.Value {
+	return &qdrant.Value{Kind: &qdrant.Value_NullValue{}}
+}
+
 func UnmarshalScoredPoint(marshalled *qdrant.ScoredPoint, item any) error {
 	t := reflect.TypeOf(item)
-	if t.Kind() != reflect.Pointer {
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
 		return fmt.Errorf("item should be a pointer to struct, not a %T", item)
 	}
 
 	value := reflect.ValueOf(item)
 	value = value.Elem()
 
-	for i := 0; i < value.NumField(); i++ {
-		field := value.Type().Field(i)
-		fieldValue := value.Field(i)
-
-		tagVal := field.Tag.Get(tag)
-		switch tagVal {
-		case "":
-			continue
-		case "_id":
+	for _, f := range fields(value.Type()) {
+		switch f.name {
+		case idField:
+			fieldValue := fieldByIndex(value, f.index, true)
+			if fieldValue.Kind() != reflect.String {
+				return fmt.Errorf("_id should be of type string, not %s", f.typ)
+			}
 			fieldValue.SetString(marshalled.Id.GetUuid())
-		case "_vector":
+		case vectorField:
+			fieldValue := fieldByIndex(value, f.index, true)
 			_, ok := fieldValue.Interface().([]float32)
 			if !ok {
 				return fmt.Errorf("_vector should be of type []float32")
 			}
 			fieldValue.Set(reflect.ValueOf(marshalled.Vectors.GetVector().GetData()))
+		case scoreField:
+			fieldValue := fieldByIndex(value, f.index, true)
+			if fieldValue.Kind() != reflect.Float32 && fieldValue.Kind() != reflect.Float64 {
+				return fmt.Errorf("_score should be of type float32 or float64, not %s", f.typ)
+			}
+			fieldValue.SetFloat(float64(marshalled.Score))
+		default:
+			payloadVal, exist := marshalled.Payload[f.name]
+			if !exist {
+				continue
+			}
+			if err := unmarshalValue(payloadVal, fieldByIndex(value, f.index, true)); err != nil {
+				return fmt.Errorf("failed to unmarshal payload field %s: %v", f.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalValue sets v to the payload value, null resets pointers, slices, maps and interfaces like encoding/json does
+func unmarshalValue(pv *qdrant.Value, v reflect.Value) error {
+	if _, isNull := pv.GetKind().(*qdrant.Value_NullValue); isNull || pv.GetKind() == nil {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return unmarshalValue(pv, v.Elem())
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(natural(pv)))
+		return nil
+	}
+	if v.Type() == timeType {
+		s, ok := pv.GetKind().(*qdrant.Value_StringValue)
+		if !ok {
+			return mismatch(pv, v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s.StringValue)
+		if err != nil {
+			return fmt.Errorf("invalid time: %v", err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch kind := pv.GetKind().(type) {
+	case *qdrant.Value_StringValue:
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(kind.StringValue)
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			bb, err := base64.StdEncoding.DecodeString(kind.StringValue)
+			if err != nil {
+				return fmt.Errorf("invalid bytes: %v", err)
+			}
+			v.SetBytes(bb)
 		default:
-			if field.Type.Kind() != reflect.String {
-				return fmt.Errorf("item payload field should be a string, not a %s", field.Type)
+			return mismatch(pv, v)
+		}
+	case *qdrant.Value_BoolValue:
+		if v.Kind() != reflect.Bool {
+			return mismatch(pv, v)
+		}
+		v.SetBool(kind.BoolValue)
+	case *qdrant.Value_IntegerValue:
+		return setNumber(v, float64(kind.IntegerValue), kind.IntegerValue, pv)
+	case *qdrant.Value_DoubleValue:
+		n := kind.DoubleValue
+		if n != math.Trunc(n) && v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return fmt.Errorf("number %v is not an integer of %s", n, v.Type())
+		}
+		return setNumber(v, n, int64(n), pv)
+	case *qdrant.Value_ListValue:
+		values := kind.ListValue.GetValues()
+		switch v.Kind() {
+		case reflect.Slice:
+			slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+			for i, elem := range values {
+				if err := unmarshalValue(elem, slice.Index(i)); err != nil {
+					return fmt.Errorf("[%d]: %v", i, err)
+				}
 			}
-			payloadVal, exist := marshalled.Payload[tagVal]
+			v.Set(slice)
+		case reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				elem := v.Index(i)
+				if i >= len(values) {
+					elem.Set(reflect.Zero(elem.Type()))
+					continue
+				}
+				if err := unmarshalValue(values[i], elem); err != nil {
+					return fmt.Errorf("[%d]: %v", i, err)
+				}
+			}
+		default:
+			return mismatch(pv, v)
+		}
+	case *qdrant.Value_StructValue:
+		return unmarshalStruct(kind.StructValue.GetFields(), v, pv)
+	default:
+		return mismatch(pv, v)
+	}
+	return nil
+}
+
+func unmarshalStruct(values map[string]*qdrant.Value, v reflect.Value, pv *qdrant.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range fields(v.Type()) {
+			elem, exist := values[f.name]
 			if !exist {
 				continue
 			}
-			fieldValue.SetString(payloadVal.GetStringValue())
+			if err := unmarshalValue(elem, fieldByIndex(v, f.index, true)); err != nil {
+				return fmt.Errorf("%s: %v", f.name, err)
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(values)))
+		}
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key, err := parseMapKey(k, v.Type().Key())
+			if err != nil {
+				return err
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := unmarshalValue(values[k], elem); err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+			v.SetMapIndex(key, elem)
 		}
+	default:
+		return mismatch(pv, v)
 	}
 	return nil
 }
 
+func parseMapKey(k string, t reflect.Type) (reflect.Value, error) {
+	key := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		key.SetString(k)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(k, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %s: %v", k, err)
+		}
+		key.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(k, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %s: %v", k, err)
+		}
+		key.SetUint(n)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported map key type %s", t)
+	}
+	return key, nil
+}
+
+func setNumber(v reflect.Value, f float64, i int64, pv *qdrant.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(i) {
+			return fmt.Errorf("number %d overflows %s", i, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return fmt.Errorf("number %d overflows %s", i, v.Type())
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		if v.OverflowFloat(f) {
+			return fmt.Errorf("number %v overflows %s", f, v.Type())
+		}
+		v.SetFloat(f)
+	default:
+		return mismatch(pv, v)
+	}
+	return nil
+}
+
+// natural converts the payload value into string, int64, float64, bool, []any, map[string]any or nil
+func natural(pv *qdrant.Value) any {
+	switch kind := pv.GetKind().(type) {
+	case *qdrant.Value_StringValue:
+		return kind.StringValue
+	case *qdrant.Value_IntegerValue:
+		return kind.IntegerValue
+	case *qdrant.Value_DoubleValue:
+		return kind.DoubleValue
+	case *qdrant.Value_BoolValue:
+		return kind.BoolValue
+	case *qdrant.Value_ListValue:
+		list := make([]any, len(kind.ListValue.GetValues()))
+		for i, elem := range kind.ListValue.GetValues() {
+			list[i] = natural(elem)
+		}
+		return list
+	case *qdrant.Value_StructValue:
+		m := make(map[string]any, len(kind.StructValue.GetFields()))
+		for k, elem := range kind.StructValue.GetFields() {
+			m[k] = natural(elem)
+		}
+		return m
+	}
+	return nil
+}
+
+func mismatch(pv *qdrant.Value, v reflect.Value) error {
+	return fmt.Errorf("cannot set %T into %s", pv.GetKind(), v.Type())
+}
+
 func UnmarshalScoredPoints(marshalled []*qdrant.ScoredPoint, items any) error {
 	t := reflect.TypeOf(items)
 	if t.Kind() != reflect.Pointer {
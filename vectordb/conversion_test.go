@@ -2,9 +2,11 @@ package vectordb
 
 import (
 	"testing"
+	"time"
 
 	qdrant "github.com/qdrant/go-client/qdrant"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type Item struct {
@@ -178,3 +180,133 @@ func TestShouldUnmarshalScoredPoints(t *testing.T) {
 	assert.ElementsMatch(t, expectedData, unmarshalled)
 	assert.Equal(t, len(expectedData), len(unmarshalled))
 }
+
+type Address struct {
+	City   string `qdrant:"city"`
+	Street string `qdrant:"street,omitempty"`
+}
+
+type Audit struct {
+	Created time.Time `qdrant:"created"`
+	Author  string    `qdrant:"author"`
+}
+
+type Rich struct {
+	Audit
+	ID       string         `qdrant:"_id"`
+	Vector   []float32      `qdrant:"_vector"`
+	Name     string         `qdrant:"name"`
+	Age      int            `qdrant:"age"`
+	Score    float64        `qdrant:"score"`
+	Active   bool           `qdrant:"active"`
+	Tags     []string       `qdrant:"tags"`
+	Home     Address        `qdrant:"home"`
+	Work     *Address       `qdrant:"work"`
+	Counts   map[string]int `qdrant:"counts"`
+	Extra    any            `qdrant:"extra"`
+	Raw      []byte         `qdrant:"raw"`
+	Nickname string         `qdrant:"nickname,omitempty"`
+	Secret   string         `qdrant:"-"`
+	Untagged string
+	Nested   map[string]Address `qdrant:"nested,omitempty"`
+}
+
+func TestShouldRoundTripTypedPayload(t *testing.T) {
+	// given
+	item := Rich{
+		Audit:  Audit{Created: time.Date(2024, 1, 12, 10, 30, 0, 0, time.UTC), Author: "koenno"},
+		ID:     "e5b5c018-c511-4249-b139-ea4c9dc6668b",
+		Vector: []float32{0.5, 0.5},
+		Name:   "Zygfryd",
+		Age:    42,
+		Score:  0.75,
+		Active: true,
+		Tags:   []string{"go", "ai"},
+		Home:   Address{City: "Kraków"},
+		Work:   &Address{City: "Warszawa", Street: "Marszałkowska"},
+		Counts: map[string]int{"a": 1, "b": 2},
+		Extra:  map[string]any{"level": int64(3), "ratio": 0.5, "list": []any{"x", true}},
+		Raw:    []byte{0, 1, 2},
+		Secret: "secret",
+	}
+
+	// when
+	marshalled, err := Marshal(&item)
+	require.NoError(t, err)
+	var unmarshalled Rich
+	err = UnmarshalScoredPoint(&qdrant.ScoredPoint{Id: marshalled.Id, Vectors: marshalled.Vectors, Payload: marshalled.Payload}, &unmarshalled)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), marshalled.Payload["age"].GetIntegerValue())
+	assert.Equal(t, "2024-01-12T10:30:00Z", marshalled.Payload["created"].GetStringValue())
+	assert.Equal(t, "koenno", marshalled.Payload["author"].GetStringValue())
+	assert.NotContains(t, marshalled.Payload, "street", "omitempty of nested struct")
+	assert.NotContains(t, marshalled.Payload, "nickname")
+	assert.NotContains(t, marshalled.Payload, "nested")
+	assert.NotContains(t, marshalled.Payload, "Secret")
+	assert.NotContains(t, marshalled.Payload, "Untagged")
+	item.Secret = ""
+	assert.Equal(t, item, unmarshalled)
+}
+
+func TestShouldMarshalNilPointersAsNull(t *testing.T) {
+	// given
+	item := Rich{ID: "e5b5c018-c511-4249-b139-ea4c9dc6668b"}
+	unmarshalled := Rich{Work: &Address{City: "old"}}
+
+	// when
+	marshalled, err := Marshal(item)
+	require.NoError(t, err)
+	err = UnmarshalScoredPoint(&qdrant.ScoredPoint{Id: marshalled.Id, Payload: marshalled.Payload}, &unmarshalled)
+
+	// then
+	require.NoError(t, err)
+	assert.IsType(t, &qdrant.Value_NullValue{}, marshalled.Payload["work"].GetKind())
+	assert.Nil(t, unmarshalled.Work)
+	assert.Nil(t, unmarshalled.Tags)
+}
+
+func TestShouldRejectMismatchedPayload(t *testing.T) {
+	// given
+	point := &qdrant.ScoredPoint{
+		Payload: map[string]*qdrant.Value{
+			"age": {Kind: &qdrant.Value_StringValue{StringValue: "forty"}},
+		},
+	}
+	var unmarshalled Rich
+
+	// when
+	err := UnmarshalScoredPoint(point, &unmarshalled)
+
+	// then
+	assert.ErrorContains(t, err, "age")
+}
+
+func TestShouldRejectUnsupportedPayload(t *testing.T) {
+	// given
+	item := struct {
+		Callback func() `qdrant:"callback"`
+	}{}
+
+	// when
+	_, err := Marshal(item)
+
+	// then
+	assert.ErrorContains(t, err, "unsupported type func()")
+}
+
+func TestShouldLetShallowerFieldHideEmbeddedOne(t *testing.T) {
+	// given
+	item := struct {
+		Audit
+		Author int `qdrant:"author"`
+	}{Audit: Audit{Author: "hidden"}, Author: 7}
+
+	// when
+	marshalled, err := Marshal(item)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), marshalled.Payload["author"].GetIntegerValue())
+}
@@ -0,0 +1,221 @@
+package vectordb
+
+import (
+	"fmt"
+	"strings"
+
+	qdrant "github.com/qdrant/go-client/qdrant"
+)
+
+// Filter narrows search down to points whose payload matches all Must, at least one Should and none of MustNot conditions
+type Filter struct {
+	Must    []Condition
+	Should  []Condition
+	MustNot []Condition
+}
+
+// Condition is a single payload condition, keys may reach into nested objects with dots like home.city
+type Condition struct {
+	c *qdrant.Condition
+}
+
+// MatchKeyword matches points whose string value, or any value of a list, equals value
+func MatchKeyword(key, value string) Condition {
+	return match(key, &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: value}})
+}
+
+// MatchAny matches points whose string value equals any of values
+func MatchAny(key string, values ...string) Condition {
+	return match(key, &qdrant.Match{MatchValue: &qdrant.Match_Keywords{Keywords: &qdrant.RepeatedStrings{Strings: values}}})
+}
+
+// MatchInteger matches points whose integer value, or any value of a list, equals value
+func MatchInteger(key string, value int64) Condition {
+	return match(key, &qdrant.Match{MatchValue: &qdrant.Match_Integer{Integer: value}})
+}
+
+// MatchBool matches points whose bool value equals value
+func MatchBool(key string, value bool) Condition {
+	return match(key, &qdrant.Match{MatchValue: &qdrant.Match_Boolean{Boolean: value}})
+}
+
+func match(key string, m *qdrant.Match) Condition {
+	return Condition{c: &qdrant.Condition{
+		ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: key, Match: m}},
+	}}
+}
+
+// Bound limits a range
+type Bound func(*qdrant.Range)
+
+// Gt requires values greater than v
+func Gt(v float64) Bound {
+	return func(r *qdrant.Range) {
+		r.Gt = &v
+	}
+}
+
+// Gte requires values greater than or equal to v
+func Gte(v float64) Bound {
+	return func(r *qdrant.Range) {
+		r.Gte = &v
+	}
+}
+
+// Lt requires values less than v
+func Lt(v float64) Bound {
+	return func(r *qdrant.Range) {
+		r.Lt = &v
+	}
+}
+
+// Lte requires values less than or equal to v
+func Lte(v float64) Bound {
+	return func(r *qdrant.Range) {
+		r.Lte = &v
+	}
+}
+
+// Range matches points whose number value lies within all bounds
+func Range(key string, bounds ...Bound) Condition {
+	r := &qdrant.Range{}
+	for _, b := range bounds {
+		b(r)
+	}
+	return Condition{c: &qdrant.Condition{
+		ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: key, Range: r}},
+	}}
+}
+
+// Group nests the filter as a condition, e.g. to require one of a few conditions besides must conditions
+func Group(f Filter) Condition {
+	return Condition{c: &qdrant.Condition{
+		ConditionOneOf: &qdrant.Condition_Filter{Filter: f.qdrant()},
+	}}
+}
+
+func (f Filter) qdrant() *qdrant.Filter {
+	conditions := func(cc []Condition) []*qdrant.Condition {
+		result := make([]*qdrant.Condition, len(cc))
+		for i, c := range cc {
+			result[i] = c.c
+		}
+		return result
+	}
+	return &qdrant.Filter{
+		Must:    conditions(f.Must),
+		Should:  conditions(f.Should),
+		MustNot: conditions(f.MustNot),
+	}
+}
+
+// matchFilter evaluates the filter the way Qdrant does, it is used by Memory
+func matchFilter(f *qdrant.Filter, payload map[string]*qdrant.Value) (bool, error) {
+	for _, c := range f.GetMust() {
+		ok, err := matchCondition(c, payload)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	for _, c := range f.GetMustNot() {
+		ok, err := matchCondition(c, payload)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+	if len(f.GetShould()) == 0 {
+		return true, nil
+	}
+	for _, c := range f.GetShould() {
+		ok, err := matchCondition(c, payload)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchCondition(c *qdrant.Condition, payload map[string]*qdrant.Value) (bool, error) {
+	switch cond := c.GetConditionOneOf().(type) {
+	case *qdrant.Condition_Filter:
+		return matchFilter(cond.Filter, payload)
+	case *qdrant.Condition_Field:
+		values := lookup(payload, cond.Field.GetKey())
+		for _, v := range values {
+			ok, err := matchField(cond.Field, v)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported condition %T", c.GetConditionOneOf())
+}
+
+// lookup finds values under the dotted key, lists are flattened so a condition matches when any element does
+func lookup(payload map[string]*qdrant.Value, key string) []*qdrant.Value {
+	name, rest, nested := strings.Cut(key, ".")
+	v, exist := payload[name]
+	if !exist {
+		return nil
+	}
+	values := []*qdrant.Value{v}
+	if list := v.GetListValue(); list != nil {
+		values = list.GetValues()
+	}
+	if !nested {
+		return values
+	}
+	var result []*qdrant.Value
+	for _, v := range values {
+		if s := v.GetStructValue(); s != nil {
+			result = append(result, lookup(s.GetFields(), rest)...)
+		}
+	}
+	return result
+}
+
+func matchField(f *qdrant.FieldCondition, v *qdrant.Value) (bool, error) {
+	switch {
+	case f.GetMatch() != nil:
+		switch m := f.GetMatch().GetMatchValue().(type) {
+		case *qdrant.Match_Keyword:
+			s, ok := v.GetKind().(*qdrant.Value_StringValue)
+			return ok && s.StringValue == m.Keyword, nil
+		case *qdrant.Match_Keywords:
+			s, ok := v.GetKind().(*qdrant.Value_StringValue)
+			if !ok {
+				return false, nil
+			}
+			for _, k := range m.Keywords.GetStrings() {
+				if s.StringValue == k {
+					return true, nil
+				}
+			}
+			return false, nil
+		case *qdrant.Match_Integer:
+			i, ok := v.GetKind().(*qdrant.Value_IntegerValue)
+			return ok && i.IntegerValue == m.Integer, nil
+		case *qdrant.Match_Boolean:
+			b, ok := v.GetKind().(*qdrant.Value_BoolValue)
+			return ok && b.BoolValue == m.Boolean, nil
+		}
+		return false, fmt.Errorf("unsupported match %T", f.GetMatch().GetMatchValue())
+	case f.GetRange() != nil:
+		var n float64
+		switch kind := v.GetKind().(type) {
+		case *qdrant.Value_IntegerValue:
+			n = float64(kind.IntegerValue)
+		case *qdrant.Value_DoubleValue:
+			n = kind.DoubleValue
+		default:
+			return false, nil
+		}
+		r := f.GetRange()
+		return (r.Gt == nil || n > *r.Gt) &&
+			(r.Gte == nil || n >= *r.Gte) &&
+			(r.Lt == nil || n < *r.Lt) &&
+			(r.Lte == nil || n <= *r.Lte), nil
+	}
+	return false, fmt.Errorf("unsupported field condition of %s", f.GetKey())
+}
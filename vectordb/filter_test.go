@@ -0,0 +1,151 @@
+package vectordb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Place struct {
+	City string `qdrant:"city"`
+}
+
+type Article struct {
+	ID     string    `qdrant:"_id"`
+	Vector []float32 `qdrant:"_vector"`
+	Score  float64   `qdrant:"_score"`
+	Name   string    `qdrant:"name"`
+	Year   int       `qdrant:"year"`
+	Draft  bool      `qdrant:"draft"`
+	Tags   []string  `qdrant:"tags"`
+	Places []Place   `qdrant:"places"`
+}
+
+func newArticles(t *testing.T) *Memory {
+	ctx := context.Background()
+	sut, err := NewMemory()
+	require.NoError(t, err)
+	require.NoError(t, sut.CreateCollection(ctx, collectionName))
+	require.NoError(t, sut.UpsertMany(ctx, collectionName, []any{
+		Article{ID: "2f1d8a4e-0000-4000-8000-000000000001", Vector: vector(1, 0), Name: "rodo", Year: 2018, Tags: []string{"law", "privacy"}, Places: []Place{{City: "Brussels"}}},
+		Article{ID: "2f1d8a4e-0000-4000-8000-000000000002", Vector: vector(1, 1), Name: "pizza", Year: 2021, Tags: []string{"food"}, Places: []Place{{City: "Naples"}, {City: "Rome"}}},
+		Article{ID: "2f1d8a4e-0000-4000-8000-000000000003", Vector: vector(0, 1), Name: "k8s", Year: 2023, Draft: true, Tags: []string{"devops"}},
+	}))
+	return sut
+}
+
+func names(articles []Article) []string {
+	result := make([]string, len(articles))
+	for i, a := range articles {
+		result[i] = a.Name
+	}
+	return result
+}
+
+func TestShouldFilterByPayload(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{
+			name:     "keyword in list",
+			filter:   Filter{Must: []Condition{MatchKeyword("tags", "privacy")}},
+			expected: []string{"rodo"},
+		},
+		{
+			name:     "any keyword",
+			filter:   Filter{Must: []Condition{MatchAny("name", "k8s", "pizza")}},
+			expected: []string{"pizza", "k8s"},
+		},
+		{
+			name:     "integer and bool",
+			filter:   Filter{Must: []Condition{MatchInteger("year", 2023), MatchBool("draft", true)}},
+			expected: []string{"k8s"},
+		},
+		{
+			name:     "range",
+			filter:   Filter{Must: []Condition{Range("year", Gt(2018), Lte(2023))}},
+			expected: []string{"pizza", "k8s"},
+		},
+		{
+			name:     "nested key",
+			filter:   Filter{Must: []Condition{MatchKeyword("places.city", "Rome")}},
+			expected: []string{"pizza"},
+		},
+		{
+			name:     "should",
+			filter:   Filter{Should: []Condition{MatchKeyword("name", "rodo"), MatchKeyword("name", "k8s")}},
+			expected: []string{"rodo", "k8s"},
+		},
+		{
+			name:     "must not",
+			filter:   Filter{MustNot: []Condition{MatchBool("draft", true)}},
+			expected: []string{"rodo", "pizza"},
+		},
+		{
+			name: "group",
+			filter: Filter{
+				Must:    []Condition{Range("year", Gte(2018))},
+				MustNot: []Condition{Group(Filter{Must: []Condition{MatchKeyword("tags", "food"), Range("year", Lt(2022))}})},
+			},
+			expected: []string{"rodo", "k8s"},
+		},
+		{
+			name:     "missing key",
+			filter:   Filter{Must: []Condition{MatchKeyword("author", "nobody")}},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			sut := newArticles(t)
+
+			// when
+			var found []Article
+			err := sut.Search(context.Background(), collectionName, vector(1, 0), &found, WithLimit(10), WithFilter(tc.filter))
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, names(found))
+		})
+	}
+}
+
+func TestShouldReturnScoresAboveThreshold(t *testing.T) {
+	// given
+	sut := newArticles(t)
+
+	// when
+	var found []Article
+	err := sut.Search(context.Background(), collectionName, vector(1, 0), &found, WithLimit(10), WithScoreThreshold(0.5))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rodo", "pizza"}, names(found))
+	assert.InDelta(t, 1, found[0].Score, 1e-6)
+	assert.InDelta(t, 0.7071, found[1].Score, 1e-4)
+}
+
+func TestShouldPageThroughResults(t *testing.T) {
+	// given
+	sut := newArticles(t)
+
+	// when
+	var first, second, beyond []Article
+	errFirst := sut.Search(context.Background(), collectionName, vector(1, 0), &first, WithLimit(2))
+	errSecond := sut.Search(context.Background(), collectionName, vector(1, 0), &second, WithLimit(2), WithOffset(2))
+	errBeyond := sut.Search(context.Background(), collectionName, vector(1, 0), &beyond, WithLimit(2), WithOffset(5))
+
+	// then
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.NoError(t, errBeyond)
+	assert.Equal(t, []string{"rodo", "pizza"}, names(first))
+	assert.Equal(t, []string{"k8s"}, names(second))
+	assert.Empty(t, beyond)
+}
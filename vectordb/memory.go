@@ -0,0 +1,273 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	qdrant "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	ErrCollectionExists   = errors.New("collection already exists")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrVectorSize         = errors.New("wrong vector size")
+	ErrLimit              = errors.New("limit must be 1 or larger")
+)
+
+// Memory is a vector store kept in memory which behaves like DB, it is optionally persisted to a file
+type Memory struct {
+	mu          sync.RWMutex
+	path        string
+	collections map[string]map[string]*qdrant.PointStruct
+}
+
+type MemoryOption func(*Memory)
+
+// WithFile loads the store from the file when it exists and saves it there after every change
+func WithFile(path string) MemoryOption {
+	return func(m *Memory) {
+		m.path = path
+	}
+}
+
+func NewMemory(opts ...MemoryOption) (*Memory, error) {
+	m := &Memory{
+		collections: make(map[string]map[string]*qdrant.PointStruct),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	if m.path == "" {
+		return m, nil
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Close does nothing as every change is saved right away, it is there to match DB
+func (m *Memory) Close() {}
+
+func (m *Memory) CreateCollection(ctx context.Context, collectionName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exist := m.collections[collectionName]; exist {
+		return fmt.Errorf("failed to create collection '%s': %w", collectionName, ErrCollectionExists)
+	}
+	m.collections[collectionName] = make(map[string]*qdrant.PointStruct)
+	if err := m.save(); err != nil {
+		delete(m.collections, collectionName)
+		return fmt.Errorf("failed to create collection '%s': %w", collectionName, err)
+	}
+	return nil
+}
+
+func (m *Memory) CollectionExist(ctx context.Context, collectionName string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exist := m.collections[collectionName]
+	return exist, nil
+}
+
+func (m *Memory) UpsertOne(ctx context.Context, collectionName string, item any) error {
+	return m.UpsertMany(ctx, collectionName, []any{item})
+}
+
+// UpsertMany replaces points of the same id, vectors are normalized as Qdrant does for the cosine distance
+func (m *Memory) UpsertMany(ctx context.Context, collectionName string, items []any) error {
+	points := make([]*qdrant.PointStruct, len(items))
+	for i, item := range items {
+		p, err := Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal item: %v", err)
+		}
+		if p.Id.GetUuid() == "" {
+			return fmt.Errorf("failed to upsert vector: item has no _id")
+		}
+		vector := p.Vectors.GetVector().GetData()
+		if len(vector) != VectorSize {
+			return fmt.Errorf("failed to upsert vector: %w: expected %d, got %d", ErrVectorSize, VectorSize, len(vector))
+		}
+		p.Vectors.GetVector().Data = normalize(vector)
+		points[i] = p
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	collection, exist := m.collections[collectionName]
+	if !exist {
+		return fmt.Errorf("failed to upsert vector: %w: %s", ErrCollectionNotFound, collectionName)
+	}
+	for _, p := range points {
+		collection[p.Id.GetUuid()] = p
+	}
+	if err := m.save(); err != nil {
+		return fmt.Errorf("failed to upsert vector: %w", err)
+	}
+	return nil
+}
+
+// Search finds points closest to the vector by cosine similarity, the best match first, a field tagged _score gets the similarity of the point
+func (m *Memory) Search(ctx context.Context, collectionName string, vector []float32, items any, options ...SearchOption) error {
+	opts := &searchOptions{}
+	for _, o := range options {
+		o(opts)
+	}
+	if opts.limit == 0 {
+		return fmt.Errorf("failed to search vector: %w", ErrLimit)
+	}
+	if len(vector) != VectorSize {
+		return fmt.Errorf("failed to search vector: %w: expected %d, got %d", ErrVectorSize, VectorSize, len(vector))
+	}
+	query := normalize(vector)
+
+	m.mu.RLock()
+	collection, exist := m.collections[collectionName]
+	if !exist {
+		m.mu.RUnlock()
+		return fmt.Errorf("failed to search vector: %w: %s", ErrCollectionNotFound, collectionName)
+	}
+	scored := make([]*qdrant.ScoredPoint, 0, len(collection))
+	for _, p := range collection {
+		if opts.filter != nil {
+			ok, err := matchFilter(opts.filter, p.Payload)
+			if err != nil {
+				m.mu.RUnlock()
+				return fmt.Errorf("failed to search vector: %v", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		data := p.Vectors.GetVector().GetData()
+		score := dot(query, data)
+		if opts.scoreThreshold != nil && score < *opts.scoreThreshold {
+			continue
+		}
+		scored = append(scored, &qdrant.ScoredPoint{
+			Id:      p.Id,
+			Payload: p.Payload,
+			Score:   score,
+			// the copy keeps the stored vector intact when the caller modifies the found one
+			Vectors: &qdrant.Vectors{
+				VectorsOptions: &qdrant.Vectors_Vector{Vector: &qdrant.Vector{Data: append([]float32(nil), data...)}},
+			},
+		})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Id.GetUuid() < scored[j].Id.GetUuid()
+	})
+	scored = scored[min(opts.offset, uint64(len(scored))):]
+	if uint64(len(scored)) > opts.limit {
+		scored = scored[:opts.limit]
+	}
+	return UnmarshalScoredPoints(scored, items)
+}
+
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	normalized := make([]float32, len(vector))
+	norm := math.Sqrt(sum)
+	if norm == 0 {
+		return normalized
+	}
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized
+}
+
+func dot(a, b []float32) float32 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return float32(sum)
+}
+
+func (m *Memory) load() error {
+	bb, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read vector store %s: %v", m.path, err)
+	}
+	var stored map[string][]json.RawMessage
+	if err := json.Unmarshal(bb, &stored); err != nil {
+		return fmt.Errorf("failed to decode vector store %s: %v", m.path, err)
+	}
+	for name, points := range stored {
+		collection := make(map[string]*qdrant.PointStruct, len(points))
+		for _, raw := range points {
+			p := &qdrant.PointStruct{}
+			if err := protojson.Unmarshal(raw, p); err != nil {
+				return fmt.Errorf("failed to decode point of collection %s in %s: %v", name, m.path, err)
+			}
+			collection[p.Id.GetUuid()] = p
+		}
+		m.collections[name] = collection
+	}
+	return nil
+}
+
+// save writes the store to a temporary file first so a failed write does not corrupt it, the caller holds the lock
+func (m *Memory) save() error {
+	if m.path == "" {
+		return nil
+	}
+	stored := make(map[string][]json.RawMessage, len(m.collections))
+	for name, collection := range m.collections {
+		ids := make([]string, 0, len(collection))
+		for id := range collection {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		points := make([]json.RawMessage, len(ids))
+		for i, id := range ids {
+			raw, err := protojson.Marshal(collection[id])
+			if err != nil {
+				return fmt.Errorf("failed to encode point %s: %v", id, err)
+			}
+			points[i] = raw
+		}
+		stored[name] = points
+	}
+	bb, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode vector store: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create vector store file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bb); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vector store file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write vector store file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("failed to replace vector store file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package vectordb
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const collectionName = "some-collection"
+
+// vector returns a vector of VectorSize with the given leading values
+func vector(values ...float32) []float32 {
+	v := make([]float32, VectorSize)
+	copy(v, values)
+	return v
+}
+
+func items() []any {
+	return []any{
+		Item{ID: "2f1d8a4e-0000-4000-8000-000000000001", Vector: vector(1, 0), Name: "east", URL: "http://east"},
+		Item{ID: "2f1d8a4e-0000-4000-8000-000000000002", Vector: vector(0, 2), Name: "north", URL: "http://north"},
+		Item{ID: "2f1d8a4e-0000-4000-8000-000000000003", Vector: vector(3, 3), Name: "north-east", URL: "http://north-east"},
+	}
+}
+
+func TestShouldFindClosestItemsByCosineSimilarity(t *testing.T) {
+	// given
+	ctx := context.Background()
+	sut, err := NewMemory()
+	require.NoError(t, err)
+	require.NoError(t, sut.CreateCollection(ctx, collectionName))
+	require.NoError(t, sut.UpsertMany(ctx, collectionName, items()))
+
+	// when
+	var found []Item
+	err = sut.Search(ctx, collectionName, vector(10, 1), &found, WithLimit(2))
+
+	// then
+	assert.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "east", found[0].Name)
+	assert.Equal(t, "north-east", found[1].Name)
+	assert.Equal(t, "http://north-east", found[1].URL)
+	assert.InDelta(t, 1/math.Sqrt2, found[1].Vector[0], 1e-6, "vectors are normalized")
+}
+
+func TestShouldReplaceItemOfTheSameID(t *testing.T) {
+	// given
+	ctx := context.Background()
+	sut, err := NewMemory()
+	require.NoError(t, err)
+	require.NoError(t, sut.CreateCollection(ctx, collectionName))
+	require.NoError(t, sut.UpsertMany(ctx, collectionName, items()))
+	moved := Item{ID: "2f1d8a4e-0000-4000-8000-000000000002", Vector: vector(-1, 0), Name: "west"}
+
+	// when
+	err = sut.UpsertOne(ctx, collectionName, moved)
+
+	// then
+	assert.NoError(t, err)
+	var found []Item
+	require.NoError(t, sut.Search(ctx, collectionName, vector(-1, 0), &found, WithLimit(10)))
+	require.Len(t, found, 3)
+	assert.Equal(t, "west", found[0].Name)
+}
+
+func TestShouldReportCollections(t *testing.T) {
+	// given
+	ctx := context.Background()
+	sut, err := NewMemory()
+	require.NoError(t, err)
+
+	// when
+	existBefore, errBefore := sut.CollectionExist(ctx, collectionName)
+	errCreate := sut.CreateCollection(ctx, collectionName)
+	existAfter, errAfter := sut.CollectionExist(ctx, collectionName)
+	errDuplicate := sut.CreateCollection(ctx, collectionName)
+
+	// then
+	assert.NoError(t, errBefore)
+	assert.False(t, existBefore)
+	assert.NoError(t, errCreate)
+	assert.NoError(t, errAfter)
+	assert.True(t, existAfter)
+	assert.ErrorIs(t, errDuplicate, ErrCollectionExists)
+}
+
+func TestShouldRejectInvalidRequests(t *testing.T) {
+	// given
+	ctx := context.Background()
+	sut, err := NewMemory()
+	require.NoError(t, err)
+	require.NoError(t, sut.CreateCollection(ctx, collectionName))
+	var found []Item
+
+	// when
+	errMissingUpsert := sut.UpsertMany(ctx, "missing", items())
+	errMissingSearch := sut.Search(ctx, "missing", vector(1), &found, WithLimit(1))
+	errSize := sut.UpsertOne(ctx, collectionName, Item{ID: "2f1d8a4e-0000-4000-8000-000000000009", Vector: []float32{1, 2}})
+	errLimit := sut.Search(ctx, collectionName, vector(1), &found)
+
+	// then
+	assert.ErrorIs(t, errMissingUpsert, ErrCollectionNotFound)
+	assert.ErrorIs(t, errMissingSearch, ErrCollectionNotFound)
+	assert.ErrorIs(t, errSize, ErrVectorSize)
+	assert.ErrorIs(t, errLimit, ErrLimit)
+}
+
+func TestShouldPersistStoreToFile(t *testing.T) {
+	// given
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.json")
+	stored, err := NewMemory(WithFile(path))
+	require.NoError(t, err)
+	require.NoError(t, stored.CreateCollection(ctx, collectionName))
+	require.NoError(t, stored.UpsertMany(ctx, collectionName, items()))
+	stored.Close()
+
+	// when
+	sut, err := NewMemory(WithFile(path))
+
+	// then
+	require.NoError(t, err)
+	exist, err := sut.CollectionExist(ctx, collectionName)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	var found []Item
+	require.NoError(t, sut.Search(ctx, collectionName, vector(0, 1), &found, WithLimit(1)))
+	require.Len(t, found, 1)
+	assert.Equal(t, "north", found[0].Name)
+	assert.Equal(t, "2f1d8a4e-0000-4000-8000-000000000002", found[0].ID)
+}
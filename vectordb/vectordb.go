@@ -5,24 +5,43 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/koenno/aidevs2/recording"
+	"github.com/koenno/aidevs2/resilience"
 	qdrant "github.com/qdrant/go-client/qdrant"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
-	VectorSize = 1536
-	Distance   = qdrant.Distance_Cosine
+	VectorSize       = 1536
+	Distance         = qdrant.Distance_Cosine
+	DefaultBatchSize = 100
 )
 
 type DB struct {
-	conn *grpc.ClientConn
+	conn      *grpc.ClientConn
+	batchSize int
 }
 
-func New(addr string) (*DB, error) {
-	db := &DB{}
+type Option func(*DB)
+
+// WithBatchSize sets how many points are sent by a single upsert request
+func WithBatchSize(n int) Option {
+	return func(db *DB) {
+		db.batchSize = n
+	}
+}
+
+func New(addr string, opts ...Option) (*DB, error) {
+	db := &DB{
+		batchSize: DefaultBatchSize,
+	}
+	for _, o := range opts {
+		o(db)
+	}
 	var err error
-	db.conn, err = grpc.DialContext(context.Background(), addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	db.conn, err = grpc.DialContext(context.Background(), addr, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(recording.UnaryInterceptor(), resilience.UnaryInterceptor()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to qdrant: %v", err)
 	}
@@ -59,27 +78,31 @@ func (db *DB) CreateCollection(ctx context.Context, collectionName string) error
 }
 
 func (db *DB) UpsertOne(ctx context.Context, collectionName string, item any) error {
-	data, err := Marshal(item)
-	if err != nil {
-		return fmt.Errorf("failed to marshal item: %v", err)
-	}
-	waitUpsert := true
-	client := qdrant.NewPointsClient(db.conn)
-	_, err = client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: collectionName,
-		Wait:           &waitUpsert,
-		Points:         []*qdrant.PointStruct{data},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to upsert vector: %v", err)
-	}
-	return nil
+	return db.UpsertMany(ctx, collectionName, []any{item})
 }
 
+// UpsertMany sends items in batches, every batch is applied before the next one is sent
 func (db *DB) UpsertMany(ctx context.Context, collectionName string, items []any) error {
-	for _, item := range items {
-		if err := db.UpsertOne(ctx, collectionName, item); err != nil {
-			return fmt.Errorf("failed to upsert many: %v", err)
+	points := make([]*qdrant.PointStruct, len(items))
+	for i, item := range items {
+		data, err := Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal item: %v", err)
+		}
+		points[i] = data
+	}
+	waitUpsert := true
+	client := qdrant.NewPointsClient(db.conn)
+	size := max(db.batchSize, 1)
+	for start := 0; start < len(points); start += size {
+		end := min(start+size, len(points))
+		_, err := client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: collectionName,
+			Wait:           &waitUpsert,
+			Points:         points[start:end],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert vectors %d-%d: %v", start, end-1, err)
 		}
 	}
 	return nil
@@ -93,10 +116,35 @@ func WithLimit(n uint64) SearchOption {
 	}
 }
 
+// WithFilter returns only points whose payload matches the filter
+func WithFilter(f Filter) SearchOption {
+	return func(so *searchOptions) {
+		so.filter = f.qdrant()
+	}
+}
+
+// WithScoreThreshold returns only points at least as similar as min
+func WithScoreThreshold(min float32) SearchOption {
+	return func(so *searchOptions) {
+		so.scoreThreshold = &min
+	}
+}
+
+// WithOffset skips the first n results, together with WithLimit it pages through results
+func WithOffset(n uint64) SearchOption {
+	return func(so *searchOptions) {
+		so.offset = n
+	}
+}
+
 type searchOptions struct {
-	limit uint64
+	limit          uint64
+	offset         uint64
+	filter         *qdrant.Filter
+	scoreThreshold *float32
 }
 
+// Search finds points closest to the vector, the best match first, a field tagged _score gets the similarity of the point
 func (db *DB) Search(ctx context.Context, collectionName string, vector []float32, items any, options ...SearchOption) error {
 	opts := &searchOptions{}
 	for _, o := range options {
@@ -107,6 +155,9 @@ func (db *DB) Search(ctx context.Context, collectionName string, vector []float3
 		CollectionName: collectionName,
 		Vector:         vector,
 		Limit:          opts.limit,
+		Offset:         &opts.offset,
+		Filter:         opts.filter,
+		ScoreThreshold: opts.scoreThreshold,
 		// Include all payload and vectors in the search result
 		WithVectors: &qdrant.WithVectorsSelector{SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: true}},
 		WithPayload: &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
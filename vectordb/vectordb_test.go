@@ -0,0 +1,110 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/koenno/aidevs2/config"
+	qdrant "github.com/qdrant/go-client/qdrant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakePointsServer struct {
+	qdrant.UnimplementedPointsServer
+	mu      sync.Mutex
+	batches []int
+	search  *qdrant.SearchPoints
+}
+
+func (s *fakePointsServer) Upsert(ctx context.Context, req *qdrant.UpsertPoints) (*qdrant.PointsOperationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, len(req.Points))
+	return &qdrant.PointsOperationResponse{}, nil
+}
+
+func (s *fakePointsServer) Search(ctx context.Context, req *qdrant.SearchPoints) (*qdrant.SearchResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.search = req
+	return &qdrant.SearchResponse{Result: []*qdrant.ScoredPoint{{
+		Id:      &qdrant.PointId{PointIdOptions: &qdrant.PointId_Uuid{Uuid: "2f1d8a4e-0000-4000-8000-000000000001"}},
+		Payload: map[string]*qdrant.Value{"name": {Kind: &qdrant.Value_StringValue{StringValue: "rodo"}}},
+		Score:   0.9,
+	}}}, nil
+}
+
+func newFakeDB(t *testing.T, server *fakePointsServer, opts ...Option) *DB {
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	qdrant.RegisterPointsServer(srv, server)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	db := &DB{conn: conn, batchSize: DefaultBatchSize}
+	for _, o := range opts {
+		o(db)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestShouldUpsertItemsInBatches(t *testing.T) {
+	// given
+	server := &fakePointsServer{}
+	sut := newFakeDB(t, server, WithBatchSize(10))
+	var items []any
+	for i := 0; i < 25; i++ {
+		items = append(items, Item{ID: fmt.Sprintf("2f1d8a4e-0000-4000-8000-%012d", i), Vector: vector(1)})
+	}
+
+	// when
+	err := sut.UpsertMany(context.Background(), collectionName, items)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10, 10, 5}, server.batches)
+}
+
+func TestShouldPassSearchOptionsToQdrant(t *testing.T) {
+	// given
+	server := &fakePointsServer{}
+	sut := newFakeDB(t, server)
+
+	// when
+	var found []Article
+	err := sut.Search(context.Background(), collectionName, vector(1), &found,
+		WithLimit(5), WithOffset(10), WithScoreThreshold(0.8),
+		WithFilter(Filter{Must: []Condition{MatchKeyword("tags", "law")}, MustNot: []Condition{Range("year", Lt(2000))}}))
+
+	// then
+	assert.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "rodo", found[0].Name)
+	assert.InDelta(t, 0.9, found[0].Score, 1e-6)
+	req := server.search
+	assert.Equal(t, uint64(5), req.GetLimit())
+	assert.Equal(t, uint64(10), req.GetOffset())
+	assert.Equal(t, float32(0.8), req.GetScoreThreshold())
+	require.Len(t, req.GetFilter().GetMust(), 1)
+	assert.Equal(t, "law", req.GetFilter().GetMust()[0].GetField().GetMatch().GetKeyword())
+	require.Len(t, req.GetFilter().GetMustNot(), 1)
+	assert.Equal(t, 2000.0, req.GetFilter().GetMustNot()[0].GetField().GetRange().GetLt())
+}
+
+func TestShouldShareDefaultBatchSizeWithConfig(t *testing.T) {
+	assert.Equal(t, DefaultBatchSize, config.DefaultVectorDBBatchSize)
+	assert.Equal(t, DefaultBatchSize, config.Default().VectorDB.BatchSize)
+}